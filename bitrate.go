@@ -0,0 +1,195 @@
+package lame
+
+import (
+	"errors"
+)
+
+// Typed bitrate control of EncodeOptions, instead of the raw setters of Lame, e.g.,
+// "V2 VBR":  BitrateOptions{Mode: BITRATE_MODE_VBR, VBRQuality: 2}
+// "ABR 128": BitrateOptions{Mode: BITRATE_MODE_ABR, Kbps: 128}
+// "CBR 320": BitrateOptions{Mode: BITRATE_MODE_CBR, Kbps: 320}
+// or a named preset: BitrateOptions{Preset: PRESET_EXTREME}
+
+type (
+	// how the bitrate is controlled
+	BitrateMode int
+
+	// preset_mode_e
+	Preset int
+
+	BitrateOptions struct {
+		Mode       BitrateMode `json:"mode,omitempty"`        // BITRATE_MODE_DEFAULT leaves it to lame (CBR 128kbps), or to Preset if any
+		Kbps       int         `json:"kbps,omitempty"`        // bitrate of CBR, or the target of ABR
+		MinKbps    int         `json:"min_kbps,omitempty"`    // minimum bitrate of ABR and VBR, 0 if no limit
+		MaxKbps    int         `json:"max_kbps,omitempty"`    // maximum bitrate of ABR and VBR, 0 if no limit
+		VBRQuality float32     `json:"vbr_quality,omitempty"` // quality of VBR, 0-highest, 9.999-lowest, e.g., 2 for V2
+		HardMin    bool        `json:"hard_min,omitempty"`    // strictly enforce MinKbps, which is normally violated for analog silence
+		Preset     Preset      `json:"preset,omitempty"`      // applied first, so that MinKbps, MaxKbps and HardMin could adjust it
+	}
+)
+
+// let us define bitrate modes here
+const (
+	BITRATE_MODE_DEFAULT BitrateMode = iota
+	BITRATE_MODE_CBR
+	BITRATE_MODE_ABR
+	BITRATE_MODE_VBR
+)
+
+// let us define presets here
+// values from 8 to 320 are ABR presets, targeting the value as kbps
+const (
+	PRESET_NONE          Preset = 0
+	PRESET_ABR_8         Preset = 8
+	PRESET_ABR_320       Preset = 320
+	PRESET_V9            Preset = 410
+	PRESET_V8            Preset = 420
+	PRESET_V7            Preset = 430
+	PRESET_V6            Preset = 440
+	PRESET_V5            Preset = 450
+	PRESET_V4            Preset = 460
+	PRESET_V3            Preset = 470
+	PRESET_V2            Preset = 480
+	PRESET_V1            Preset = 490
+	PRESET_V0            Preset = 500
+	PRESET_R3MIX         Preset = 1000 /* still there for compatibility */
+	PRESET_STANDARD      Preset = 1001
+	PRESET_EXTREME       Preset = 1002
+	PRESET_INSANE        Preset = 1003
+	PRESET_STANDARD_FAST Preset = 1004
+	PRESET_EXTREME_FAST  Preset = 1005
+	PRESET_MEDIUM        Preset = 1006
+	PRESET_MEDIUM_FAST   Preset = 1007
+)
+
+const (
+	_MIN_KBPS = 8
+	_MAX_KBPS = 320
+)
+
+var (
+	ErrInvalidBitrateMode = errors.New("invalid bitrate mode")
+	ErrInvalidBitrate     = errors.New("invalid bitrate, expected 8-320 kbps")
+	ErrInvalidVBRQuality  = errors.New("invalid VBR quality, expected 0 (highest) to 9.999 (lowest)")
+	ErrInvalidPreset      = errors.New("invalid preset")
+	ErrBitrateConflict    = errors.New("conflicting bitrate options")
+)
+
+// an ABR preset of the given bitrate
+func PresetABR(kbps int) Preset {
+	return Preset(kbps)
+}
+
+func (p Preset) valid() bool {
+	switch {
+	case p >= PRESET_ABR_8 && p <= PRESET_ABR_320:
+		return true
+	case p >= PRESET_V9 && p <= PRESET_V0:
+		return p%10 == 0
+	default:
+		return p >= PRESET_R3MIX && p <= PRESET_MEDIUM_FAST
+	}
+}
+
+func validKbps(kbps int) bool {
+	return kbps >= _MIN_KBPS && kbps <= _MAX_KBPS
+}
+
+// check the options on their own, and against each other, returns the invalid field, e.g., "MinKbps", along with the error
+// options which do not apply to the mode are conflicts, rather than being ignored silently
+func (b *BitrateOptions) validate() (field string, err error) {
+	if b.MinKbps != 0 && !validKbps(b.MinKbps) {
+		return "MinKbps", ErrInvalidBitrate
+	}
+	if b.MaxKbps != 0 && !validKbps(b.MaxKbps) {
+		return "MaxKbps", ErrInvalidBitrate
+	}
+	if b.MinKbps != 0 && b.MaxKbps != 0 && b.MinKbps > b.MaxKbps {
+		return "MinKbps", ErrBitrateConflict // min above max
+	}
+	if b.HardMin && b.MinKbps == 0 {
+		return "HardMin", ErrBitrateConflict // nothing to enforce
+	}
+	if b.Preset != PRESET_NONE {
+		if !b.Preset.valid() {
+			return "Preset", ErrInvalidPreset
+		}
+		if b.Mode != BITRATE_MODE_DEFAULT || b.Kbps != 0 || b.VBRQuality != 0 {
+			return "Preset", ErrBitrateConflict // the preset decides them
+		}
+		return "", nil
+	}
+	switch b.Mode {
+	case BITRATE_MODE_DEFAULT:
+		if b.Kbps != 0 || b.MinKbps != 0 || b.MaxKbps != 0 || b.VBRQuality != 0 {
+			return "Mode", ErrBitrateConflict // no mode to apply them
+		}
+	case BITRATE_MODE_CBR:
+		if !validKbps(b.Kbps) {
+			return "Kbps", ErrInvalidBitrate
+		}
+		if b.MinKbps != 0 || b.MaxKbps != 0 {
+			return "Mode", ErrBitrateConflict // CBR has no bounds
+		}
+		if b.VBRQuality != 0 {
+			return "VBRQuality", ErrBitrateConflict // nor a quality
+		}
+	case BITRATE_MODE_ABR:
+		if !validKbps(b.Kbps) {
+			return "Kbps", ErrInvalidBitrate
+		}
+		if b.VBRQuality != 0 {
+			return "VBRQuality", ErrBitrateConflict // ABR targets a bitrate, not a quality
+		}
+		if b.MinKbps != 0 && b.MinKbps > b.Kbps || b.MaxKbps != 0 && b.MaxKbps < b.Kbps {
+			return "Kbps", ErrBitrateConflict // target out of the bounds
+		}
+	case BITRATE_MODE_VBR:
+		if b.VBRQuality < 0 || b.VBRQuality >= 10 {
+			return "VBRQuality", ErrInvalidVBRQuality
+		}
+		if b.Kbps != 0 {
+			return "Kbps", ErrBitrateConflict // VBR targets a quality, not a bitrate
+		}
+	default:
+		return "Mode", ErrInvalidBitrateMode
+	}
+	return "", nil
+}
+
+// apply the options onto the given lame, which are supposed to be validated by EncodeOptions.Validate
+func (b *BitrateOptions) apply(l *Lame) error {
+	var setters []func() error
+	if b.Preset != PRESET_NONE {
+		setters = append(setters, func() error { return l.SetPreset(b.Preset) })
+	}
+	switch b.Mode {
+	case BITRATE_MODE_CBR:
+		setters = append(setters,
+			func() error { return l.SetVBR(VBR_OFF) },
+			func() error { return l.SetBrate(b.Kbps) })
+	case BITRATE_MODE_ABR:
+		setters = append(setters,
+			func() error { return l.SetVBR(VBR_ABR) },
+			func() error { return l.SetVBRMeanBitrateKbps(b.Kbps) })
+	case BITRATE_MODE_VBR:
+		setters = append(setters,
+			func() error { return l.SetVBR(VBR_DEFAULT) },
+			func() error { return l.SetVBRQuality(b.VBRQuality) })
+	}
+	if b.MinKbps != 0 {
+		setters = append(setters, func() error { return l.SetVBRMinBitrateKbps(b.MinKbps) })
+	}
+	if b.MaxKbps != 0 {
+		setters = append(setters, func() error { return l.SetVBRMaxBitrateKbps(b.MaxKbps) })
+	}
+	if b.HardMin {
+		setters = append(setters, func() error { return l.SetVBRHardMin(1) })
+	}
+	for _, set := range setters {
+		if err := set(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package lame
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func Test_BitrateOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  BitrateOptions
+		field    string
+		expected error
+	}{
+		{"default", BitrateOptions{}, "", nil},
+		{"cbr 320", BitrateOptions{Mode: BITRATE_MODE_CBR, Kbps: 320}, "", nil},
+		{"abr 128", BitrateOptions{Mode: BITRATE_MODE_ABR, Kbps: 128, MinKbps: 96, MaxKbps: 192}, "", nil},
+		{"v2", BitrateOptions{Mode: BITRATE_MODE_VBR, VBRQuality: 2, MinKbps: 64, HardMin: true}, "", nil},
+		{"preset", BitrateOptions{Preset: PRESET_EXTREME}, "", nil},
+		{"preset with bounds", BitrateOptions{Preset: PRESET_V2, MaxKbps: 256}, "", nil},
+		{"abr preset", BitrateOptions{Preset: PresetABR(96)}, "", nil},
+		{"cbr without bitrate", BitrateOptions{Mode: BITRATE_MODE_CBR}, "Kbps", ErrInvalidBitrate},
+		{"cbr 400", BitrateOptions{Mode: BITRATE_MODE_CBR, Kbps: 400}, "Kbps", ErrInvalidBitrate},
+		{"max 400", BitrateOptions{Mode: BITRATE_MODE_VBR, MaxKbps: 400}, "MaxKbps", ErrInvalidBitrate},
+		{"cbr with bounds", BitrateOptions{Mode: BITRATE_MODE_CBR, Kbps: 128, MinKbps: 64}, "Mode", ErrBitrateConflict},
+		{"abr out of bounds", BitrateOptions{Mode: BITRATE_MODE_ABR, Kbps: 128, MaxKbps: 96}, "Kbps", ErrBitrateConflict},
+		{"abr with quality", BitrateOptions{Mode: BITRATE_MODE_ABR, Kbps: 128, VBRQuality: 2}, "VBRQuality", ErrBitrateConflict},
+		{"vbr quality 10", BitrateOptions{Mode: BITRATE_MODE_VBR, VBRQuality: 10}, "VBRQuality", ErrInvalidVBRQuality},
+		{"vbr with bitrate", BitrateOptions{Mode: BITRATE_MODE_VBR, Kbps: 128}, "Kbps", ErrBitrateConflict},
+		{"min above max", BitrateOptions{Mode: BITRATE_MODE_VBR, MinKbps: 192, MaxKbps: 128}, "MinKbps", ErrBitrateConflict},
+		{"hard min without min", BitrateOptions{Mode: BITRATE_MODE_VBR, HardMin: true}, "HardMin", ErrBitrateConflict},
+		{"bounds without mode", BitrateOptions{MaxKbps: 128}, "Mode", ErrBitrateConflict},
+		{"unknown preset", BitrateOptions{Preset: 415}, "Preset", ErrInvalidPreset},
+		{"preset with mode", BitrateOptions{Preset: PRESET_V0, Mode: BITRATE_MODE_CBR, Kbps: 320}, "Preset", ErrBitrateConflict},
+		{"unknown mode", BitrateOptions{Mode: 9}, "Mode", ErrInvalidBitrateMode},
+	}
+	for _, test := range tests {
+		if field, err := test.options.validate(); field != test.field || err != test.expected {
+			t.Errorf("%s, expected %s %v, got %s %v", test.name, test.field, test.expected, field, err)
+		}
+	}
+}
+
+func Test_BitrateOptions_Apply(t *testing.T) {
+	tests := []struct {
+		name    string
+		options BitrateOptions
+		vbr     VBRMode
+		kbps    func(*Lame) (int, error)
+	}{
+		{"cbr", BitrateOptions{Mode: BITRATE_MODE_CBR, Kbps: 160}, VBR_OFF, (*Lame).GetBrate},
+		{"abr", BitrateOptions{Mode: BITRATE_MODE_ABR, Kbps: 128}, VBR_ABR, (*Lame).GetVBRMeanBitrateKbps},
+		{"vbr", BitrateOptions{Mode: BITRATE_MODE_VBR, VBRQuality: 2, MinKbps: 64}, VBR_DEFAULT, (*Lame).GetVBRMinBitrateKbps},
+	}
+	for _, test := range tests {
+		wr, err := NewWriter(new(bytes.Buffer))
+		if err != nil {
+			t.Errorf("cannot create lame writer, %s", err.Error())
+			return
+		}
+		wr.EncodeOptions = monoOptions()
+		wr.Bitrate = test.options
+		if err = wr.ForceUpdateParams(); err != nil {
+			t.Errorf("%s, cannot update params, %s", test.name, err.Error())
+		}
+		if vbr, _ := wr.lame.GetVBR(); vbr != test.vbr {
+			t.Errorf("%s, expected vbr mode %d, got %d", test.name, test.vbr, vbr)
+		}
+		var expected = test.options.Kbps
+		if test.options.Mode == BITRATE_MODE_VBR {
+			expected = test.options.MinKbps
+		}
+		if kbps, _ := test.kbps(wr.lame); kbps != expected {
+			t.Errorf("%s, expected %d kbps, got %d", test.name, expected, kbps)
+		}
+		wr.Close()
+	}
+
+	// conflicts are reported before lame is initialized
+	wr, _ := NewWriter(new(bytes.Buffer))
+	defer wr.Close()
+	wr.Bitrate = BitrateOptions{Mode: BITRATE_MODE_VBR, Kbps: 128}
+	if _, err := wr.Write(make([]byte, 4)); !errors.Is(err, ErrBitrateConflict) {
+		t.Errorf("expected ErrBitrateConflict, got %v", err)
+	}
+}
@@ -0,0 +1,168 @@
+package lame
+
+import (
+	"errors"
+	"math/bits"
+)
+
+// Downmixing/upmixing of interleaved multi-channel input into the channels OutMode requires
+// default coefficients follow ITU-R BS.775: front channels at full level, center, side and back ones at -3dB, LFE dropped
+// the mixed samples are clamped into [-1, 1], in case loud channels add up beyond full scale
+
+type (
+	// coefficients of a mix, rows are output channels (1 for mono, or left & right), columns are input channels
+	// e.g., {{1, 0, 0.7071}, {0, 1, 0.7071}} mixes L, R, C into stereo
+	ChannelMatrix [][]float32
+)
+
+// speaker positions, as dwChannelMask of WAVE_FORMAT_EXTENSIBLE
+// channels are interleaved in the order of these bits
+const (
+	SPEAKER_FRONT_LEFT uint32 = 1 << iota
+	SPEAKER_FRONT_RIGHT
+	SPEAKER_FRONT_CENTER
+	SPEAKER_LOW_FREQUENCY
+	SPEAKER_BACK_LEFT
+	SPEAKER_BACK_RIGHT
+	SPEAKER_FRONT_LEFT_OF_CENTER
+	SPEAKER_FRONT_RIGHT_OF_CENTER
+	SPEAKER_BACK_CENTER
+	SPEAKER_SIDE_LEFT
+	SPEAKER_SIDE_RIGHT
+	SPEAKER_TOP_CENTER
+	SPEAKER_TOP_FRONT_LEFT
+	SPEAKER_TOP_FRONT_CENTER
+	SPEAKER_TOP_FRONT_RIGHT
+	SPEAKER_TOP_BACK_LEFT
+	SPEAKER_TOP_BACK_CENTER
+	SPEAKER_TOP_BACK_RIGHT
+)
+
+// common channel layouts
+const (
+	CHANNEL_MASK_MONO    = SPEAKER_FRONT_CENTER
+	CHANNEL_MASK_STEREO  = SPEAKER_FRONT_LEFT | SPEAKER_FRONT_RIGHT
+	CHANNEL_MASK_QUAD    = CHANNEL_MASK_STEREO | SPEAKER_BACK_LEFT | SPEAKER_BACK_RIGHT
+	CHANNEL_MASK_5POINT1 = CHANNEL_MASK_QUAD | SPEAKER_FRONT_CENTER | SPEAKER_LOW_FREQUENCY
+	CHANNEL_MASK_7POINT1 = CHANNEL_MASK_5POINT1 | SPEAKER_SIDE_LEFT | SPEAKER_SIDE_RIGHT
+)
+
+const (
+	_ITU_ATTENUATION = 0.7071 // -3dB
+)
+
+var (
+	ErrInvalidChannelMatrix = errors.New("invalid channel matrix, expected a row of InNumChannels coefficients for each output channel")
+	ErrInvalidChannelSelect = errors.New("invalid channel to select, expected 1 to InNumChannels")
+)
+
+// the layout assumed if the channel mask is unknown, as WAVE_FORMAT_EXTENSIBLE suggests
+func defaultChannelMask(numChannels int) uint32 {
+	switch numChannels {
+	case 1:
+		return CHANNEL_MASK_MONO
+	case 2:
+		return CHANNEL_MASK_STEREO
+	case 3:
+		return CHANNEL_MASK_STEREO | SPEAKER_FRONT_CENTER
+	case 4:
+		return CHANNEL_MASK_QUAD
+	case 5:
+		return CHANNEL_MASK_QUAD | SPEAKER_FRONT_CENTER
+	case 6:
+		return CHANNEL_MASK_5POINT1
+	case 7:
+		return CHANNEL_MASK_5POINT1 | SPEAKER_BACK_CENTER
+	case 8:
+		return CHANNEL_MASK_7POINT1
+	default:
+		return 0 // no idea, every channel is regarded as a center one
+	}
+}
+
+// level of the speaker on left and right output
+func speakerCoefficients(speaker uint32) (left, right float32) {
+	switch speaker {
+	case SPEAKER_FRONT_LEFT, SPEAKER_FRONT_LEFT_OF_CENTER, SPEAKER_TOP_FRONT_LEFT:
+		return 1, 0
+	case SPEAKER_FRONT_RIGHT, SPEAKER_FRONT_RIGHT_OF_CENTER, SPEAKER_TOP_FRONT_RIGHT:
+		return 0, 1
+	case SPEAKER_BACK_LEFT, SPEAKER_SIDE_LEFT, SPEAKER_TOP_BACK_LEFT:
+		return _ITU_ATTENUATION, 0
+	case SPEAKER_BACK_RIGHT, SPEAKER_SIDE_RIGHT, SPEAKER_TOP_BACK_RIGHT:
+		return 0, _ITU_ATTENUATION
+	case SPEAKER_LOW_FREQUENCY:
+		return 0, 0
+	default: // centers, and unknown ones
+		return _ITU_ATTENUATION, _ITU_ATTENUATION
+	}
+}
+
+// the matrix mixing numChannels channels laid out as channelMask (0 if unknown) into outNumChannels (1 or 2) ones
+// the coefficients are the BS.775 gains of each speaker, and mono takes the mean of left and right
+func DefaultChannelMatrix(numChannels int, channelMask uint32, outNumChannels int) ChannelMatrix {
+	if channelMask == 0 || bits.OnesCount32(channelMask) < numChannels {
+		channelMask = defaultChannelMask(numChannels)
+	}
+	left, right := make([]float32, numChannels), make([]float32, numChannels)
+	mask := channelMask
+	for i := 0; i < numChannels; i++ {
+		speaker := mask & -mask // the lowest bit, or 0 if there are more channels than speakers
+		mask ^= speaker
+		left[i], right[i] = speakerCoefficients(speaker)
+	}
+	if outNumChannels == 1 {
+		for i := range left {
+			left[i] = (left[i] + right[i]) / 2
+		}
+		return ChannelMatrix{left}
+	}
+	return ChannelMatrix{left, right}
+}
+
+// the matrix taking only the given channel (1-based) into all outNumChannels channels
+func SelectChannelMatrix(numChannels int, channel int, outNumChannels int) ChannelMatrix {
+	matrix := make(ChannelMatrix, outNumChannels)
+	for i := range matrix {
+		matrix[i] = make([]float32, numChannels)
+		matrix[i][channel-1] = 1
+	}
+	return matrix
+}
+
+func (m ChannelMatrix) validate(numChannels, outNumChannels int) error {
+	if len(m) != outNumChannels {
+		return ErrInvalidChannelMatrix
+	}
+	for _, row := range m {
+		if len(row) != numChannels {
+			return ErrInvalidChannelMatrix
+		}
+	}
+	return nil
+}
+
+// mix interleaved samples of numChannels channels into left (and right if the matrix has 2 rows), clamped into [-1, 1]
+// left and right must be able to hold len(samples) / numChannels elements
+func (m ChannelMatrix) mix(samples []float32, numChannels int, left, right []float32) {
+	frames := len(samples) / numChannels
+	for i := 0; i < frames; i++ {
+		frame := samples[i*numChannels : (i+1)*numChannels]
+		left[i] = mixFrame(m[0], frame)
+		if len(m) > 1 {
+			right[i] = mixFrame(m[1], frame)
+		}
+	}
+}
+
+func mixFrame(row []float32, frame []float32) (mixed float32) {
+	for i, coef := range row {
+		mixed += coef * frame[i]
+	}
+	if mixed > 1 {
+		return 1
+	} else if mixed < -1 {
+		return -1
+	}
+	return mixed
+}
@@ -0,0 +1,129 @@
+package lame
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+)
+
+func matrixAlmostEqual(expected, actual ChannelMatrix) bool {
+	if len(expected) != len(actual) {
+		return false
+	}
+	for i := range expected {
+		if len(expected[i]) != len(actual[i]) {
+			return false
+		}
+		for j := range expected[i] {
+			if math.Abs(float64(expected[i][j]-actual[i][j])) > 1e-4 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func Test_DefaultChannelMatrix(t *testing.T) {
+	const a = _ITU_ATTENUATION
+	tests := []struct {
+		name           string
+		numChannels    int
+		channelMask    uint32
+		outNumChannels int
+		expected       ChannelMatrix
+	}{
+		{"mono->stereo", 1, 0, 2, ChannelMatrix{{a}, {a}}},
+		{"stereo->mono", 2, 0, 1, ChannelMatrix{{0.5, 0.5}}},
+		{"5.1->stereo", 6, CHANNEL_MASK_5POINT1, 2, ChannelMatrix{
+			{1, 0, a, 0, a, 0},
+			{0, 1, a, 0, 0, a},
+		}},
+		{"5.1(unknown mask)->stereo", 6, 0, 2, ChannelMatrix{
+			{1, 0, a, 0, a, 0},
+			{0, 1, a, 0, 0, a},
+		}},
+		{"5.1(side)->stereo", 6, CHANNEL_MASK_STEREO | SPEAKER_FRONT_CENTER | SPEAKER_LOW_FREQUENCY | SPEAKER_SIDE_LEFT | SPEAKER_SIDE_RIGHT, 2, ChannelMatrix{
+			{1, 0, a, 0, a, 0},
+			{0, 1, a, 0, 0, a},
+		}},
+		{"7.1->stereo", 8, CHANNEL_MASK_7POINT1, 2, ChannelMatrix{
+			{1, 0, a, 0, a, 0, a, 0},
+			{0, 1, a, 0, 0, a, 0, a},
+		}},
+		{"quad->mono", 4, CHANNEL_MASK_QUAD, 1, ChannelMatrix{
+			{0.5, 0.5, a / 2, a / 2},
+		}},
+		{"10 unknown->mono", 10, 0, 1, ChannelMatrix{
+			{a, a, a, a, a, a, a, a, a, a},
+		}},
+	}
+	for _, test := range tests {
+		actual := DefaultChannelMatrix(test.numChannels, test.channelMask, test.outNumChannels)
+		if !matrixAlmostEqual(test.expected, actual) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, actual)
+		}
+	}
+}
+
+func Test_SelectChannelMatrix(t *testing.T) {
+	expected := ChannelMatrix{{0, 0, 1, 0}}
+	if actual := SelectChannelMatrix(4, 3, 1); !matrixAlmostEqual(expected, actual) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func Test_ChannelMatrix_Mix(t *testing.T) {
+	matrix := ChannelMatrix{{1, 0, 0.5}, {0, 1, 0.5}}
+	samples := []float32{0.1, 0.2, 0.4, -0.1, -0.2, 0.2}
+	left, right := make([]float32, 2), make([]float32, 2)
+	matrix.mix(samples, 3, left, right)
+	expectedLeft, expectedRight := []float32{0.3, 0}, []float32{0.4, -0.1}
+	for i := range left {
+		if math.Abs(float64(left[i]-expectedLeft[i])) > 1e-6 || math.Abs(float64(right[i]-expectedRight[i])) > 1e-6 {
+			t.Errorf("frame#%d, expected %f/%f, got %f/%f", i, expectedLeft[i], expectedRight[i], left[i], right[i])
+		}
+	}
+	// loud channels adding up are clamped
+	matrix.mix([]float32{0.9, -0.9, 0.8}, 3, left, right)
+	if left[0] != 1 || math.Abs(float64(right[0]+0.5)) > 1e-6 {
+		t.Errorf("expected 1/-0.5 clamped, got %f/%f", left[0], right[0])
+	}
+	if err := matrix.validate(3, 1); err != ErrInvalidChannelMatrix {
+		t.Errorf("expected ErrInvalidChannelMatrix, got %#v", err)
+	}
+	if err := matrix.validate(3, 2); err != nil {
+		t.Errorf("expected valid matrix, got %#v", err)
+	}
+}
+
+func Test_Encoder_MultiChannel(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     func(wr *Writer)
+		expected error
+	}{
+		{"5.1->stereo", func(wr *Writer) { wr.OutMode = MODE_STEREO }, nil},
+		{"5.1->mono", func(wr *Writer) { wr.OutMode = MODE_MONO }, nil},
+		{"select 3rd", func(wr *Writer) { wr.InSelectChannel = 3 }, nil},
+		{"select 7th", func(wr *Writer) { wr.InSelectChannel = 7 }, ErrInvalidChannelSelect},
+		{"bad matrix", func(wr *Writer) { wr.ChannelMatrix = &ChannelMatrix{{1, 1}} }, ErrInvalidChannelMatrix},
+	}
+	pcm := make([]byte, 6*2*4608)
+	for _, test := range tests {
+		wr, err := NewWriter(new(bytes.Buffer))
+		if err != nil {
+			t.Errorf("cannot create lame writer, %s", err.Error())
+			return
+		}
+		wr.InNumChannels = 6
+		wr.InChannelMask = CHANNEL_MASK_5POINT1
+		test.opts(wr)
+		n, err := wr.Write(pcm)
+		if !errors.Is(err, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, err)
+		} else if err == nil && n != len(pcm) {
+			t.Errorf("%s: expected %d bytes written, got %d", test.name, len(pcm), n)
+		}
+	}
+}
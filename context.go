@@ -0,0 +1,65 @@
+package lame
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// Cancellable encoding, e.g., to abort an encode once the client of a HTTP worker disconnects
+// ctx is checked between chunks, so that it stops within _CONTEXT_CHUNK_SAMPLES samples
+
+type (
+	// returned once ctx is done, wrapping ctx.Err()
+	CancelledError struct {
+		Err     error // context.Canceled or context.DeadlineExceeded
+		Samples int64 // count of samples (of each channel) consumed before cancelled
+	}
+)
+
+const (
+	_CONTEXT_CHUNK_SAMPLES = 8 * 1152 // samples of each channel encoded between checks of ctx
+)
+
+func (e *CancelledError) Error() string {
+	return fmt.Sprintf("encoding cancelled after %d samples, %s", e.Samples, e.Err.Error())
+}
+
+func (e *CancelledError) Unwrap() error {
+	return e.Err
+}
+
+// create a new writer, which stops encoding once ctx is done
+// Write returns *CancelledError then, and Close discards the residual data instead of flushing it
+func NewWriterContext(ctx context.Context, output io.Writer) (*Writer, error) {
+	w, err := NewWriter(output)
+	if err != nil {
+		return nil, err
+	}
+	w.ctx = ctx
+	return w, nil
+}
+
+// *CancelledError if ctx is done, nil otherwise
+func (w *Writer) contextError() error {
+	if w.ctx == nil || w.ctx.Err() == nil {
+		return nil
+	}
+	return &CancelledError{Err: w.ctx.Err(), Samples: w.samplesConsumed}
+}
+
+// encode PCM from src into dst with the given options until src reaches EOF, or ctx is done
+// returns *CancelledError if cancelled, in which case the residual data is discarded
+func EncodeContext(ctx context.Context, dst io.Writer, src io.Reader, opts EncodeOptions) error {
+	w, err := NewWriterContext(ctx, dst)
+	if err != nil {
+		return err
+	}
+	w.EncodeOptions = opts
+	defer w.lame.Close() // released even if cancelled or failed
+
+	if _, err = w.ReadFrom(src); err != nil {
+		return err
+	}
+	return w.Close()
+}
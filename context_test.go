@@ -0,0 +1,99 @@
+package lame
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+// cancels the context on the given read
+type cancellingReader struct {
+	input  io.Reader
+	cancel context.CancelFunc
+	reads  int
+	after  int
+}
+
+func (r *cancellingReader) Read(p []byte) (int, error) {
+	r.reads++
+	if r.reads == r.after {
+		r.cancel()
+	}
+	return r.input.Read(p[:4096])
+}
+
+func monoOptions() EncodeOptions {
+	return EncodeOptions{
+		InSampleRate:    16000,
+		InBitsPerSample: 16,
+		InNumChannels:   1,
+		OutSampleRate:   16000,
+		OutMode:         MODE_MONO,
+		OutQuality:      5,
+	}
+}
+
+func Test_EncodeContext(t *testing.T) {
+	fin, err := os.Open("res/1chan_s16ple.raw")
+	if err != nil {
+		t.Errorf("cannot open file, %s", err.Error())
+		return
+	}
+	defer fin.Close()
+	if err = EncodeContext(context.Background(), new(bytes.Buffer), fin, monoOptions()); err != nil {
+		t.Errorf("cannot encode, %s", err.Error())
+	}
+}
+
+func Test_EncodeContext_IncompleteReads(t *testing.T) {
+	pcm := make([]byte, 4001) // the trailing byte is not a whole sample
+	if err := EncodeContext(context.Background(), new(bytes.Buffer), iotest.OneByteReader(bytes.NewReader(pcm)), monoOptions()); err != ErrIncompleteFrame {
+		t.Errorf("expected ErrIncompleteFrame, got %v", err)
+	}
+}
+
+func Test_EncodeContext_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := EncodeContext(ctx, new(bytes.Buffer), bytes.NewReader(make([]byte, 8192)), monoOptions())
+	var cancelled *CancelledError
+	if !errors.As(err, &cancelled) || !errors.Is(err, context.Canceled) {
+		t.Errorf("expected CancelledError wrapping context.Canceled, got %v", err)
+	} else if cancelled.Samples != 0 {
+		t.Errorf("expected no samples consumed, got %d", cancelled.Samples)
+	}
+}
+
+func Test_EncodeContext_CancelledHalfway(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	src := &cancellingReader{input: bytes.NewReader(make([]byte, 4096*10)), cancel: cancel, after: 3}
+	err := EncodeContext(ctx, new(bytes.Buffer), src, monoOptions())
+	var cancelled *CancelledError
+	if !errors.As(err, &cancelled) {
+		t.Errorf("expected CancelledError, got %v", err)
+	} else if cancelled.Samples != 2*4096/2 {
+		t.Errorf("expected %d samples consumed, got %d", 2*4096/2, cancelled.Samples)
+	}
+}
+
+func Test_WriterContext_Deadline(t *testing.T) {
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	wr, err := NewWriterContext(ctx, new(bytes.Buffer))
+	if err != nil {
+		t.Errorf("cannot create lame writer, %s", err.Error())
+		return
+	}
+	if n, err := wr.Write(make([]byte, 4096)); n != 0 || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected nothing written and DeadlineExceeded, got %d, %v", n, err)
+	}
+	if err = wr.Close(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected DeadlineExceeded on close, got %v", err)
+	}
+}
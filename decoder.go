@@ -0,0 +1,172 @@
+package lame
+
+/*
+#cgo LDFLAGS: -lmp3lame
+
+#include "lame/lame.h"
+*/
+import "C"
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"runtime"
+	"unsafe"
+)
+
+// A reader decoding mp3 into PCM, with the help of hip (the decoder shipped with libmp3lame)
+// the output is always interleaved 16bit little-endian PCM, e.g., L R L R ... for stereo ones
+
+type (
+	Decoder struct {
+		input  io.Reader
+		hip    C.hip_t
+		mp3Buf []byte  // buffer for reading mp3 data from input
+		pcmL   []int16 // decoded samples of left channel (or the only channel)
+		pcmR   []int16 // decoded samples of right channel
+		// mp3data_struct, filled in once the header has been parsed
+		mp3Data C.mp3data_struct
+		// decoded but not yet read PCM
+		pending []byte
+		pcmBuf  []byte
+		// error that stops decoding, typically io.EOF
+		err error
+	}
+)
+
+const (
+	_MP3_READ_BUF_SIZE  = 4096 // bytes read from input at a time
+	_PCM_FRAME_BUF_SIZE = 1152 // max samples per channel of a single mp3 frame
+)
+
+var (
+	ErrCannotInitDecoder = errors.New("cannot init hip decoder")
+	ErrDecodeFailed      = errors.New("cannot decode mp3 data")
+	ErrNoMp3Frame        = errors.New("no mp3 frame found")
+	ErrDecoderClosed     = errors.New("decoder closed")
+)
+
+// create a decoder reading mp3 from the given reader
+// NOTE: it reads the input until the first frame is decoded, so that the stream properties are available instantly,
+// hence the input's position would be changed, even if it is not an mp3 stream
+func NewReader(input io.Reader) (*Decoder, error) {
+	d := &Decoder{
+		input:  input,
+		hip:    C.hip_decode_init(),
+		mp3Buf: make([]byte, _MP3_READ_BUF_SIZE),
+		pcmL:   make([]int16, _PCM_FRAME_BUF_SIZE),
+		pcmR:   make([]int16, _PCM_FRAME_BUF_SIZE),
+		pcmBuf: make([]byte, _PCM_FRAME_BUF_SIZE*2*2),
+	}
+	if d.hip == nil {
+		return nil, ErrCannotInitDecoder
+	}
+	runtime.SetFinalizer(d, decoderFinalizer)
+	for len(d.pending) == 0 && d.err == nil {
+		d.decodeFrame()
+	}
+	if d.mp3Data.header_parsed == 0 {
+		err := d.err
+		d.Close()
+		if err == io.EOF {
+			return nil, ErrNoMp3Frame
+		}
+		return nil, err
+	}
+	return d, nil
+}
+
+// read interleaved 16bit little-endian PCM
+func (d *Decoder) Read(p []byte) (n int, err error) {
+	for len(d.pending) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		d.decodeFrame()
+	}
+	n = copy(p, d.pending)
+	d.pending = d.pending[n:]
+	return n, nil
+}
+
+// sample rate of the stream, in Hz
+func (d *Decoder) SampleRate() int {
+	return int(d.mp3Data.samplerate)
+}
+
+// count of channels of the stream, 1 or 2
+func (d *Decoder) NumChannels() int {
+	return int(d.mp3Data.stereo)
+}
+
+// bitrate of the stream, in kbps, as reported by the decoder
+func (d *Decoder) Bitrate() int {
+	return int(d.mp3Data.bitrate)
+}
+
+// release the hip decoder, further reads would fail
+func (d *Decoder) Close() error {
+	if d.hip != nil {
+		C.hip_decode_exit(d.hip)
+		d.hip = nil
+		runtime.SetFinalizer(d, nil)
+	}
+	d.pending = nil
+	d.err = ErrDecoderClosed
+	return nil
+}
+
+func decoderFinalizer(d *Decoder) {
+	if d.hip != nil {
+		C.hip_decode_exit(d.hip)
+	}
+}
+
+// decode at most one frame, filling pending on success, or err otherwise
+func (d *Decoder) decodeFrame() {
+	// 1. frames might be buffered inside hip, drain them first
+	ret := d.decodeBuffer(0)
+	// 2. otherwise feed more data
+	for ret == 0 {
+		readSize, err := d.input.Read(d.mp3Buf)
+		if readSize > 0 {
+			ret = d.decodeBuffer(readSize)
+		}
+		if ret == 0 && err != nil {
+			d.err = err
+			return
+		}
+	}
+	if ret < 0 {
+		d.err = ErrDecodeFailed
+		return
+	}
+	d.pending = d.interleave(ret)
+}
+
+// feed size bytes of mp3Buf into hip, returns the count of samples per channel decoded
+func (d *Decoder) decodeBuffer(size int) int {
+	return int(C.hip_decode1_headers(
+		d.hip,
+		(*C.uchar)(unsafe.Pointer(&d.mp3Buf[0])), C.size_t(size),
+		(*C.short)(unsafe.Pointer(&d.pcmL[0])), (*C.short)(unsafe.Pointer(&d.pcmR[0])),
+		&d.mp3Data,
+	))
+}
+
+func (d *Decoder) interleave(sampleCount int) []byte {
+	numChannels := d.NumChannels()
+	if numChannels != 2 {
+		numChannels = 1
+	}
+	buf := d.pcmBuf[:sampleCount*numChannels*2]
+	for i := 0; i < sampleCount; i++ {
+		if numChannels == 1 {
+			binary.LittleEndian.PutUint16(buf[i*2:], uint16(d.pcmL[i]))
+		} else {
+			binary.LittleEndian.PutUint16(buf[i*4:], uint16(d.pcmL[i]))
+			binary.LittleEndian.PutUint16(buf[i*4+2:], uint16(d.pcmR[i]))
+		}
+	}
+	return buf
+}
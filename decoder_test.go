@@ -0,0 +1,55 @@
+package lame
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+)
+
+func Test_Decoder_RoundTrip(t *testing.T) {
+	data, err := ioutil.ReadFile("res/1chan_s16ple.raw")
+	if err != nil {
+		t.Errorf("cannot read file, %s", err.Error())
+		return
+	}
+	mp3 := new(bytes.Buffer)
+	wr, err := NewWriter(mp3)
+	if err != nil {
+		t.Errorf("cannot create lame writer, %s", err.Error())
+		return
+	}
+	wr.InNumChannels = 1
+	wr.InSampleRate = 16000
+	wr.OutSampleRate = 16000
+	wr.OutMode = MODE_MONO
+	if _, err = wr.Write(data); err != nil {
+		t.Errorf("cannot write, %s", err.Error())
+		return
+	}
+	wr.Close()
+
+	rd, err := NewReader(mp3)
+	if err != nil {
+		t.Errorf("cannot create decoder, %s", err.Error())
+		return
+	}
+	defer rd.Close()
+	if rd.SampleRate() != 16000 || rd.NumChannels() != 1 {
+		t.Errorf("unexpected stream properties, sampleRate=%d, numChannels=%d", rd.SampleRate(), rd.NumChannels())
+	}
+	pcm, err := ioutil.ReadAll(rd)
+	if err != nil {
+		t.Errorf("cannot decode, %s", err.Error())
+		return
+	}
+	// encoder delay and padding make the output a bit longer, but never shorter
+	if len(pcm) < len(data) || len(pcm) > len(data)+2*2*1152*2 {
+		t.Errorf("unexpected pcm size %d, the original one is %d", len(pcm), len(data))
+	}
+}
+
+func Test_Decoder_NotMp3(t *testing.T) {
+	if _, err := NewReader(bytes.NewReader(make([]byte, 1024))); err != ErrNoMp3Frame {
+		t.Errorf("expected ErrNoMp3Frame, got %#v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package lame
 
 import (
+	"context"
 	"io"
 	"errors"
 )
@@ -10,29 +11,86 @@ import (
 // 2. support mono (single channel) and stereo (2 channels ) mode
 // 3. change sample rate
 // 4. Big-endian and little-endian (input file)
+// 5. 8bit (unsigned), 16bit, 24bit and 32bit integer PCM (input file)
+// 6. 32bit and 64bit IEEE float PCM (input file)
+// 7. ID3 tags
+// 8. downmix/upmix of any count of channels, or picking a single one
+// 9. resampling of input sample rates LAME does not support, e.g., 96k or 88.2k
+// 10. cancellation through context
+// 11. CBR, ABR, VBR and presets
+// 12. optional params, e.g., filters, scale, ReplayGain and frame flags
 
 type (
 	// options for encoder
 	EncodeOptions struct {
-		InBigEndian bool // true if it is in big-endian
-		InSampleRate   int  // Hz, e.g., 8000, 16000, 12800, 44100, etc.
-		InBitsPerSample int // typically 16 should be working fine. the bit count of each sample, e.g., 2Bytes/sample->16bits
-		InNumChannels  int  // count of channels, for mono ones, please remain 1, and 2 if stereo
+		InBigEndian     bool            `json:"in_big_endian,omitempty"`     // true if it is in big-endian
+		InSampleRate    int             `json:"in_sample_rate"`              // Hz, e.g., 8000, 16000, 12800, 44100, etc. rates LAME does not support are resampled into the nearest supported one
+		InBitsPerSample int             `json:"in_bits_per_sample"`          // the bit count of each sample, e.g., 2Bytes/sample->16bits. 8 (unsigned), 16, 24 and 32 are supported for int, 32 and 64 for float
+		InSampleFormat  SampleFormat    `json:"in_sample_format,omitempty"`  // SAMPLE_FORMAT_INT (default) or SAMPLE_FORMAT_FLOAT
+		InNumChannels   int             `json:"in_num_channels"`             // count of channels, for mono ones, please remain 1, and 2 if stereo. more channels are mixed down as OutMode requires
+		InChannelMask   uint32          `json:"in_channel_mask,omitempty"`   // speaker positions of the channels, as dwChannelMask of WAVE_FORMAT_EXTENSIBLE, 0 if unknown
+		InSelectChannel int             `json:"in_select_channel,omitempty"` // encode only this channel (1-based), e.g., 3 for the 3rd channel; 0 to take all channels
+		ChannelMatrix   *ChannelMatrix  `json:"channel_matrix,omitempty"`    // custom mix of the channels, nil to use DefaultChannelMatrix if there are more than 2 channels
+		ResampleQuality ResampleQuality `json:"resample_quality,omitempty"`  // quality of resampling, if InSampleRate is not supported by LAME
 
+		OutSampleRate int            `json:"out_sample_rate"`  // Hz
+		OutMode       Mode           `json:"out_mode"`         // MODE_MONO, MODE_STEREO, etc.
+		OutQuality    int            `json:"out_quality"`      // quality: 0-highest, 9-lowest
+		Bitrate       BitrateOptions `json:"bitrate,omitzero"` // CBR, ABR, VBR or a preset, lame's default (CBR 128kbps) if left empty
 
-		OutSampleRate int  // Hz
-		OutMode       Mode // MODE_MONO, MODE_STEREO, etc.
-		OutQuality    int  // quality: 0-highest, 9-lowest
+		// optional params, applied only if set, e.g., lame.Int(16000), leaving lame's defaults otherwise
+		LowpassFreq     OptionalInt     `json:"lowpass_freq,omitzero"`     // Hz, 0 lets lame choose, -1 disables the filter
+		HighpassFreq    OptionalInt     `json:"highpass_freq,omitzero"`    // Hz, 0 lets lame choose, -1 disables the filter
+		Scale           OptionalFloat32 `json:"scale,omitzero"`            // scale the input by this amount before encoding, 1 by default
+		FindReplayGain  OptionalBool    `json:"find_replay_gain,omitzero"` // perform ReplayGain analysis
+		Copyright       OptionalBool    `json:"copyright,omitzero"`        // mark as copyright
+		Original        OptionalBool    `json:"original,omitzero"`         // mark as original, true by default
+		ErrorProtection OptionalBool    `json:"error_protection,omitzero"` // use 2 bytes of each frame for CRC checksum
+		Emphasis        OptionalInt     `json:"emphasis,omitzero"`         // 0: none, 1: 50/15 ms, 3: CCITT J.17
+		StrictISO       OptionalBool    `json:"strict_iso,omitzero"`       // enforce strict ISO compliance
+
+		Tags *Tags `json:"tags,omitempty"` // ID3 tags to be embedded, nil if no tags
 	}
 
 	Writer struct {
 		output io.Writer
 		lame *Lame
+		// position of the output where the mp3 stream starts, -1 if nothing written or output is not seekable
+		startOffset int64
+		// mix of channels before encoding, nil if channels are fed into lame as they are
+		mixer ChannelMatrix
+		// resamplers of each channel fed into lame, nil if InSampleRate is supported by LAME
+		resamplers []*Resampler
+		// checked between chunks if not nil, see NewWriterContext
+		ctx context.Context
+		// count of samples (of each channel) consumed so far
+		samplesConsumed int64
+		// bytes of an incomplete frame (one sample of each channel) left by the last Write
+		pending []byte
+		// reused across writes, see writeBuffers
+		buffers writeBuffers
 		EncodeOptions
 	}
+
+	// buffers of a Writer, grown as needed instead of being allocated on every Write
+	writeBuffers struct {
+		mp3      []byte
+		int32s   []int32   // decoded samples
+		float32s []float32 // decoded samples, followed by the planar ones
+		float64s []float64
+		input    []byte // PCM read by ReadFrom, frame-aligned
+	}
+)
+
+var (
+	ErrUnsupportedChannelNum = errors.New("invalid number of channels, expected at least 1")
+	ErrIncompleteFrame       = errors.New("incomplete frame, expected a sample of each channel")
 )
 
-var ErrUnsupportedChannelNum = errors.New("only 1 and 2 channels are supported")
+const (
+	_READ_FROM_FRAMES      = 8    // mp3 frames of input read at a time by ReadFrom
+	_MAX_MP3_FRAME_SAMPLES = 1152 // samples of each channel of an MPEG-1 frame, MPEG-2 ones are of 576
+)
 
 // create a new writer, without initializing the Lame
 func NewWriter(output io.Writer) (*Writer, error) {
@@ -43,6 +101,7 @@ func NewWriter(output io.Writer) (*Writer, error) {
 	return &Writer{
 		output: output,
 		lame: lame,
+		startOffset: -1,
 		EncodeOptions: EncodeOptions{
 			InBigEndian:  false,
 			InSampleRate:    44100,
@@ -57,82 +116,479 @@ func NewWriter(output io.Writer) (*Writer, error) {
 
 // forced to init the params inside
 // NOT NECESSARY
+// the options are validated first, see EncodeOptions.Validate
 func (w *Writer) ForceUpdateParams() (err error) {
-	if err = w.lame.SetInSampleRate(w.InSampleRate); err != nil {
+	if err = w.Validate(); err != nil {
+		return
+	}
+	if w.mixer, err = w.channelMatrix(); err != nil {
+		return
+	}
+	var numChannels = w.InNumChannels
+	if w.mixer != nil {
+		numChannels = len(w.mixer)
+	}
+	var inSampleRate = w.InSampleRate
+	w.resamplers = nil
+	if w.InSampleRate > 0 && w.lame.checkSampleRate(w.InSampleRate) != nil {
+		inSampleRate = NearestSampleRate(w.InSampleRate)
+		for i := 0; i < numChannels; i++ {
+			w.resamplers = append(w.resamplers, NewResampler(w.InSampleRate, inSampleRate, w.ResampleQuality))
+		}
+	}
+	if err = w.lame.SetInSampleRate(inSampleRate); err != nil {
 		return
 	}
 	if err = w.lame.SetOutSampleRate(w.OutSampleRate); err != nil {
 		return
 	}
-	if err = w.lame.SetNumChannels(w.InNumChannels); err != nil {
+	if err = w.lame.SetNumChannels(numChannels); err != nil {
 		return
 	}
 	if err = w.lame.SetMode(w.OutMode); err != nil {
 		return
 	}
+	if err = w.Bitrate.apply(w.lame); err != nil {
+		return
+	}
 	if err = w.lame.SetQuality(w.OutQuality); err != nil {
 		return
 	}
+	if err = w.applyOptional(w.lame); err != nil {
+		return
+	}
+	if w.Tags != nil {
+		if err = w.Tags.apply(w.lame); err != nil {
+			return
+		}
+	}
 	if err = w.lame.InitParams(); err != nil {
 		return
 	}
 	return nil
 }
 
+// count of channels the output has, according to OutMode
+func (opts *EncodeOptions) outNumChannels() int {
+	if opts.OutMode == MODE_MONO {
+		return 1
+	}
+	return 2
+}
+
+// the mix of channels, nil if mono and stereo input could be fed into lame directly
+func (w *Writer) channelMatrix() (ChannelMatrix, error) {
+	outNumChannels := w.outNumChannels()
+	switch {
+	case w.ChannelMatrix != nil:
+		return *w.ChannelMatrix, w.ChannelMatrix.validate(w.InNumChannels, outNumChannels)
+	case w.InSelectChannel != 0:
+		if w.InSelectChannel < 0 || w.InSelectChannel > w.InNumChannels {
+			return nil, ErrInvalidChannelSelect
+		}
+		return SelectChannelMatrix(w.InNumChannels, w.InSelectChannel, outNumChannels), nil
+	case w.InNumChannels > 2:
+		return DefaultChannelMatrix(w.InNumChannels, w.InChannelMask, outNumChannels), nil
+	default:
+		return nil, nil // lame takes care of mono and stereo
+	}
+}
+
 // NOT thread-safe!
 // will check if we have lame object inside first!
-// TODO: support 8bit/32bit!
-// currently, we support 16bit depth only
+// supports 8bit (unsigned), 16bit, 24bit (packed) and 32bit (signed) integer PCM, as well as 32bit and 64bit float PCM,
+// according to InSampleFormat and InBitsPerSample
+// integer samples are widened to 32bit and fed through lame_encode_buffer_int, so that no precision is lost
+// if channels have to be mixed (see ChannelMatrix) or resampled, samples are processed and encoded as float instead
+// p is consumed as a whole, i.e., n == len(p) unless failed. bytes of an incomplete frame (one sample of each channel)
+// at the end are kept, and encoded along with the next Write, so that p could be split anywhere, e.g., by io.Copy.
+// those left at Close are dropped, and reported by ErrIncompleteFrame
+// if the writer is created by NewWriterContext, p is encoded chunk by chunk, and *CancelledError returned once ctx is done
 func (w *Writer) Write(p []byte) (n int, err error) {
+	frameSize, err := w.frameSize()
+	if err != nil {
+		return 0, err
+	}
+	// complete the pending frame first
+	if len(w.pending) > 0 {
+		n = frameSize - len(w.pending)
+		if n > len(p) {
+			n = len(p)
+		}
+		w.pending = append(w.pending, p[:n]...)
+		if len(w.pending) < frameSize {
+			return n, nil
+		}
+		if _, err = w.writeFrames(w.pending); err != nil {
+			w.pending = w.pending[:frameSize-n] // as if p were not written at all
+			return 0, err
+		}
+		w.pending = w.pending[:0]
+	}
+	var complete = n + (len(p)-n)/frameSize*frameSize
+	written, err := w.writeFrames(p[n:complete])
+	if err != nil {
+		return n + written, err
+	}
+	w.pending = append(w.pending, p[complete:]...)
+	return len(p), nil
+}
+
+// encode PCM from src until EOF, returns the count of bytes read
+// src is read into a buffer reused across calls, which holds _READ_FROM_FRAMES mp3 frames of input (see GetFramesize),
+// so that reads are not split into incomplete frames, unless src returns short ones
+// stops with *CancelledError if the writer is created by NewWriterContext and ctx is done
+func (w *Writer) ReadFrom(src io.Reader) (n int64, err error) {
 	if !w.lame.paramUpdated {
 		if err = w.ForceUpdateParams(); err != nil {
 			return 0, err
 		}
 	}
+	size, err := w.inputBufSize(_READ_FROM_FRAMES)
+	if err != nil {
+		return 0, err
+	}
+	w.buffers.input = growBytes(w.buffers.input, size)
+	for {
+		if err = w.contextError(); err != nil {
+			return n, err
+		}
+		read, readErr := src.Read(w.buffers.input)
+		n += int64(read)
+		if _, err = w.Write(w.buffers.input[:read]); err != nil {
+			return n, err
+		}
+		if readErr == io.EOF {
+			return n, nil
+		} else if readErr != nil {
+			return n, readErr
+		}
+	}
+}
 
-	var samples = make([]int16, len(p) / 2)
-	var lo, hi int16 = 0x0001, 0x0100
-	if w.InBigEndian {
-		lo, hi = 0x0100, 0x0001
+// bytes of PCM encoded into count mp3 frames, see GetFramesize
+func (w *Writer) inputBufSize(count int) (int, error) {
+	frameSize, err := w.frameSize()
+	if err != nil {
+		return 0, err
 	}
-	for i := 0; i < len(samples); i++ {
-		samples[i] = int16(p[i * 2]) * lo + int16(p[i * 2 + 1]) * hi
+	samples, err := w.lame.GetFramesize()
+	if err != nil {
+		return 0, err
 	}
-	var outNumChannels = 2
-	if w.OutMode == MODE_MONO {
-		outNumChannels = 1
+	if samples <= 0 {
+		samples = _MAX_MP3_FRAME_SAMPLES
 	}
-	// inSample * (inRate / outRate) / (inNumChan / outNumChan)
-	var outSampleCount = int(int64(len(samples)) * int64(w.InSampleRate) / int64(w.OutSampleRate) * int64(outNumChannels) / int64(w.InNumChannels))
-	var mp3BufSize = int(1.25 * float32(outSampleCount) + 7200) // follow the instruction from LAME
-	var mp3Buf = make([]byte, mp3BufSize)
+	// mp3 frames are of OutSampleRate, while PCM is of InSampleRate
+	samples = int(int64(samples) * int64(w.InSampleRate) / int64(w.OutSampleRate))
+	return count * samples * frameSize, nil
+}
 
-	if w.InNumChannels != 1 && w.InNumChannels != 2 {
+// count of bytes of a frame, i.e., one sample of each channel
+func (w *Writer) frameSize() (int, error) {
+	if w.InNumChannels < 1 {
 		return 0, ErrUnsupportedChannelNum
 	}
-	if w.InNumChannels == 1 {
-		n, err = w.lame.EncodeInt16(samples, samples, mp3Buf)
-	} else if w.InNumChannels == 2 {
-		n, err = w.lame.EncodeInt16Interleaved(samples, mp3Buf)
+	sampleSize, err := bytesPerSample(w.InSampleFormat, w.InBitsPerSample)
+	if err != nil {
+		return 0, err
+	}
+	return sampleSize * w.InNumChannels, nil
+}
+
+// encode complete frames, chunk by chunk if the writer is created by NewWriterContext
+// returns the count of bytes encoded
+func (w *Writer) writeFrames(p []byte) (n int, err error) {
+	if w.ctx == nil {
+		return w.write(p)
+	}
+	frameSize, _ := w.frameSize()
+	var chunkSize = _CONTEXT_CHUNK_SAMPLES * frameSize
+	for n < len(p) {
+		if err = w.contextError(); err != nil {
+			return n, err
+		}
+		var end = n + chunkSize
+		if end > len(p) {
+			end = len(p)
+		}
+		written, err := w.write(p[n:end])
+		n += written
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// encode p, which holds complete frames only
+func (w *Writer) write(p []byte) (n int, err error) {
+	if !w.lame.paramUpdated {
+		if err = w.ForceUpdateParams(); err != nil {
+			return 0, err
+		}
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	var sampleCount = len(p) / (w.InBitsPerSample / 8)
+	w.growMp3Buf(sampleCount / w.InNumChannels)
+
+	var mp3Data []byte
+	switch {
+	case w.mixer != nil || w.resamplers != nil:
+		mp3Data, err = w.encodeProcessed(p)
+	case w.InSampleFormat == SAMPLE_FORMAT_FLOAT && w.InBitsPerSample == 32:
+		mp3Data, err = w.encodeFloat32(p)
+	case w.InSampleFormat == SAMPLE_FORMAT_FLOAT && w.InBitsPerSample == 64:
+		mp3Data, err = w.encodeFloat64(p)
+	default:
+		mp3Data, err = w.encodeInt(p)
 	}
 	if err != nil {
 		return 0, err
+	}
+	err = w.writeOutput(mp3Data)
+	w.samplesConsumed += int64(sampleCount / w.InNumChannels)
+	return len(p), err
+}
+
+// grow the mp3 buffer, so that it is large enough for encoding frames of input
+func (w *Writer) growMp3Buf(frames int) {
+	// inSample * (inRate / outRate) * outNumChan
+	var outSampleCount = int(int64(frames) * int64(w.InSampleRate) / int64(w.OutSampleRate) * int64(w.outNumChannels()))
+	w.buffers.mp3 = growBytes(w.buffers.mp3, mp3BufSize(outSampleCount))
+}
+
+// size of the buffer large enough for encoding sampleCount samples
+func mp3BufSize(sampleCount int) int {
+	return int(1.25*float32(sampleCount) + 7200) // follow the instruction from LAME
+}
+
+// buf of length n, reallocated only if it is not large enough
+func growBytes(buf []byte, n int) []byte {
+	if cap(buf) < n {
+		return make([]byte, n)
+	}
+	return buf[:n]
+}
+
+func growInt32(buf []int32, n int) []int32 {
+	if cap(buf) < n {
+		return make([]int32, n)
+	}
+	return buf[:n]
+}
+
+func growFloat32(buf []float32, n int) []float32 {
+	if cap(buf) < n {
+		return make([]float32, n)
+	}
+	return buf[:n]
+}
+
+func growFloat64(buf []float64, n int) []float64 {
+	if cap(buf) < n {
+		return make([]float64, n)
+	}
+	return buf[:n]
+}
+
+// mix and resample the channels first, then encode them as float
+// returns the encoded data
+func (w *Writer) encodeProcessed(p []byte) ([]byte, error) {
+	var count = len(p) / (w.InBitsPerSample / 8)
+	var frames = count / w.InNumChannels
+	w.buffers.float32s = growFloat32(w.buffers.float32s, count+frames*2)
+	var samples = w.buffers.float32s[:count]
+	if _, err := decodeSamplesAsFloat32(p, w.InSampleFormat, w.InBitsPerSample, w.InBigEndian, samples, &w.buffers); err != nil {
+		return nil, err
+	}
+	return w.encodeMixed(samples, w.buffers.float32s[count:count+frames], w.buffers.float32s[count+frames:])
+}
+
+// mix and resample the interleaved float samples, then encode them
+// left and right are the buffers of the planar channels, each of which holds a sample per frame
+func (w *Writer) encodeMixed(samples, left, right []float32) ([]byte, error) {
+	var channels [][]float32
+	switch {
+	case w.mixer != nil:
+		w.mixer.mix(samples, w.InNumChannels, left, right)
+		channels = [][]float32{left, right}[:len(w.mixer)]
+	case w.InNumChannels == 1:
+		channels = [][]float32{samples}
+	default:
+		deinterleaveFloat32(samples, left, right)
+		channels = [][]float32{left, right}
+	}
+	for i, resampler := range w.resamplers {
+		channels[i] = resampler.Process(channels[i])
+	}
+	return w.encodeChannels(channels)
+}
+
+// encode mono or stereo float samples, returns the encoded data
+// the mp3 buffer is grown if resampling makes the channels longer
+func (w *Writer) encodeChannels(channels [][]float32) ([]byte, error) {
+	if len(channels[0]) == 0 {
+		return nil, nil
+	}
+	if size := mp3BufSize(len(channels[0])); len(w.buffers.mp3) < size {
+		w.buffers.mp3 = growBytes(w.buffers.mp3, size)
+	}
+	var right = channels[len(channels)-1] // left again if mono
+	n, err := w.lame.EncodeFloat32(channels[0], right, w.buffers.mp3)
+	if err != nil {
+		return nil, err
+	}
+	return w.buffers.mp3[:n], nil
+}
+
+func (w *Writer) encodeInt(p []byte) ([]byte, error) {
+	var count = len(p) / (w.InBitsPerSample / 8)
+	w.buffers.int32s = growInt32(w.buffers.int32s, count)
+	var samples = w.buffers.int32s
+	if _, err := decodeIntSamples(p, w.InBitsPerSample, w.InBigEndian, samples); err != nil {
+		return nil, err
+	}
+	var n int
+	var err error
+	if w.InNumChannels == 1 {
+		n, err = w.lame.EncodeInt32(samples, samples, w.buffers.mp3)
 	} else {
-		_, err = w.output.Write(mp3Buf[:n])
+		n, err = w.lame.EncodeInt32Interleaved(samples, w.buffers.mp3)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return w.buffers.mp3[:n], nil
+}
 
-		return 2 * len(samples), err
+func (w *Writer) encodeFloat32(p []byte) ([]byte, error) {
+	w.buffers.float32s = growFloat32(w.buffers.float32s, len(p)/4)
+	var samples = w.buffers.float32s
+	decodeFloat32Samples(p, w.InBigEndian, samples)
+	var n int
+	var err error
+	if w.InNumChannels == 1 {
+		n, err = w.lame.EncodeFloat32(samples, samples, w.buffers.mp3)
+	} else {
+		n, err = w.lame.EncodeFloat32Interleaved(samples, w.buffers.mp3)
+	}
+	if err != nil {
+		return nil, err
 	}
+	return w.buffers.mp3[:n], nil
+}
 
+func (w *Writer) encodeFloat64(p []byte) ([]byte, error) {
+	w.buffers.float64s = growFloat64(w.buffers.float64s, len(p)/8)
+	var samples = w.buffers.float64s
+	decodeFloat64Samples(p, w.InBigEndian, samples)
+	var n int
+	var err error
+	if w.InNumChannels == 1 {
+		n, err = w.lame.EncodeFloat64(samples, samples, w.buffers.mp3)
+	} else {
+		n, err = w.lame.EncodeFloat64Interleaved(samples, w.buffers.mp3)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return w.buffers.mp3[:n], nil
 }
 
+// flush the residual data, and if the output is an io.WriteSeeker,
+// rewrite the placeholder frame at the beginning with the real Xing/LAME tag
+// NOTE: the output should not be opened with O_APPEND, otherwise the tag would be appended instead
+// if the writer is created by NewWriterContext and ctx is done, the residual data is discarded instead
+// the native encoder is released afterwards, and closing more than once does nothing
+// returns ErrIncompleteFrame if bytes of an incomplete frame are left from Write, which are dropped
 func (w *Writer) Close() error {
-	// try to get some residual data
-	if residual, err := w.lame.EncodeFlush(); err != nil {
+	if w.lame.closed {
+		return nil
+	}
+	defer w.lame.Close()
+	if err := w.contextError(); err != nil {
+		return err
+	}
+	if err := w.flush(false); err != nil {
 		return err
+	}
+	if len(w.pending) > 0 {
+		w.pending = w.pending[:0]
+		return ErrIncompleteFrame
+	}
+	return nil
+}
+
+// encode the samples held back by the resamplers, flush the residual data, and write the Xing/LAME tag
+// if nogap, the track is flushed by EncodeFlushNogap instead, leaving the samples inside lame and the resamplers
+// for the next track, and the ID3v1 tag is written here, as lame does not append it
+func (w *Writer) flush(nogap bool) error {
+	if w.resamplers != nil && !nogap {
+		var channels = make([][]float32, len(w.resamplers))
+		for i, resampler := range w.resamplers {
+			channels[i] = resampler.Flush()
+		}
+		if data, err := w.encodeChannels(channels); err != nil {
+			return err
+		} else if err = w.writeOutput(data); err != nil {
+			return err
+		}
+	}
+	var residual []byte
+	var err error
+	if nogap {
+		if residual, err = w.lame.EncodeFlushNogap(); err == nil {
+			residual = append(residual, w.lame.id3v1Tag()...)
+		}
 	} else {
-		if len(residual) > 0 {
-			_, err = w.output.Write(residual)
+		residual, err = w.lame.EncodeFlush()
+	}
+	if err != nil {
+		return err
+	}
+	if err = w.writeOutput(residual); err != nil {
+		return err
+	}
+	return w.writeLametag()
+}
+
+// write mp3 data into output, keeping a record of where the stream starts
+func (w *Writer) writeOutput(data []byte) (err error) {
+	if len(data) == 0 {
+		return nil
+	}
+	if seeker, ok := w.output.(io.WriteSeeker); ok && w.startOffset < 0 {
+		if w.startOffset, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			return err
 		}
+	}
+	_, err = w.output.Write(data)
+	return err
+}
+
+// seek back, overwrite the placeholder frame (right after the ID3v2 tag if any), and restore the position
+func (w *Writer) writeLametag() (err error) {
+	seeker, ok := w.output.(io.WriteSeeker)
+	if !ok || w.startOffset < 0 {
+		return nil
+	}
+	frame, err := w.lame.GetLametagFrame()
+	if err != nil || len(frame) == 0 {
+		return err
+	}
+	end, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err = seeker.Seek(w.startOffset+int64(w.lame.id3v2TagSize()), io.SeekStart); err != nil {
+		return err
+	}
+	if _, err = seeker.Write(frame); err != nil {
 		return err
 	}
+	_, err = seeker.Seek(end, io.SeekStart)
+	return err
 }
@@ -4,6 +4,12 @@ import (
 	"testing"
 	"os"
 	"io"
+	"io/ioutil"
+	"bytes"
+	"encoding/binary"
+	"math"
+
+	"github.com/sunicy/go-lame/mp3"
 )
 
 func Test_Encoder_Full(t *testing.T) {
@@ -25,3 +31,319 @@ func Test_Encoder_Full(t *testing.T) {
 	wr.Close()
 	fout.Close()
 }
+
+func Test_Encoder_24Bit(t *testing.T) {
+	data, err := ioutil.ReadFile("res/1chan_s16ple.raw")
+	if err != nil {
+		t.Errorf("cannot read file, %s", err.Error())
+		return
+	}
+	// widen every 16bit sample into a packed 24bit one
+	var pcm24 []byte
+	for i := 0; i+1 < len(data); i += 2 {
+		pcm24 = append(pcm24, 0, data[i], data[i+1])
+	}
+	out := new(bytes.Buffer)
+	wr, err := NewWriter(out)
+	if err != nil {
+		t.Errorf("cannot create lame writer, %s", err.Error())
+		return
+	}
+	wr.InNumChannels = 1
+	wr.InBitsPerSample = 24
+	wr.InSampleRate = 16000
+	wr.OutSampleRate = 16000
+	wr.OutMode = MODE_MONO
+
+	n, err := wr.Write(pcm24)
+	if err != nil {
+		t.Errorf("cannot write, %s", err.Error())
+	} else if n != len(pcm24) {
+		t.Errorf("expected %d bytes written, got %d", len(pcm24), n)
+	}
+	wr.Close()
+}
+
+func Test_Encoder_UnsupportedBitsPerSample(t *testing.T) {
+	wr, err := NewWriter(new(bytes.Buffer))
+	if err != nil {
+		t.Errorf("cannot create lame writer, %s", err.Error())
+		return
+	}
+	wr.InBitsPerSample = 12
+	if _, err = wr.Write(make([]byte, 64)); err != (UnsupportedBitsPerSampleError{Format: SAMPLE_FORMAT_INT, BitsPerSample: 12}) {
+		t.Errorf("expected UnsupportedBitsPerSampleError, got %#v", err)
+	}
+}
+
+func Test_Encoder_Float32(t *testing.T) {
+	data, err := ioutil.ReadFile("res/1chan_s16ple.raw")
+	if err != nil {
+		t.Errorf("cannot read file, %s", err.Error())
+		return
+	}
+	// convert every 16bit sample into a float one
+	pcmFloat := make([]byte, len(data)/2*4)
+	for i := 0; i+1 < len(data); i += 2 {
+		sample := float32(int16(binary.LittleEndian.Uint16(data[i:]))) / 32768
+		binary.LittleEndian.PutUint32(pcmFloat[i*2:], math.Float32bits(sample))
+	}
+	out := new(bytes.Buffer)
+	wr, err := NewWriter(out)
+	if err != nil {
+		t.Errorf("cannot create lame writer, %s", err.Error())
+		return
+	}
+	wr.InNumChannels = 1
+	wr.InSampleFormat = SAMPLE_FORMAT_FLOAT
+	wr.InBitsPerSample = 32
+	wr.InSampleRate = 16000
+	wr.OutSampleRate = 16000
+	wr.OutMode = MODE_MONO
+
+	n, err := wr.Write(pcmFloat)
+	if err != nil {
+		t.Errorf("cannot write, %s", err.Error())
+	} else if n != len(pcmFloat) {
+		t.Errorf("expected %d bytes written, got %d", len(pcmFloat), n)
+	}
+	wr.Close()
+}
+
+func Test_Encoder_Lametag(t *testing.T) {
+	fin, err := os.Open("res/1chan_s16ple.raw")
+	if err != nil {
+		t.Errorf("cannot open file, %s", err.Error())
+		return
+	}
+	defer fin.Close()
+	fout, err := ioutil.TempFile("", "lametag*.mp3")
+	if err != nil {
+		t.Errorf("cannot create file, %s", err.Error())
+		return
+	}
+	defer os.Remove(fout.Name())
+	defer fout.Close()
+	wr, err := NewWriter(fout)
+	if err != nil {
+		t.Errorf("cannot create lame writer, %s", err.Error())
+		return
+	}
+	wr.InNumChannels = 1
+	wr.InSampleRate = 16000
+	wr.OutSampleRate = 16000
+	wr.OutMode = MODE_MONO
+	wr.Tags = &Tags{Title: "lametag", Version: ID3_V2_ONLY}
+	if err = wr.lame.SetVBR(VBR_DEFAULT); err != nil {
+		t.Errorf("cannot set vbr, %s", err.Error())
+		return
+	}
+	if _, err = io.Copy(wr, fin); err != nil {
+		t.Errorf("cannot write into file, %s", err.Error())
+		return
+	}
+	// the native encoder is released on close
+	start := wr.lame.id3v2TagSize()
+	if err = wr.Close(); err != nil {
+		t.Errorf("cannot close, %s", err.Error())
+		return
+	}
+
+	data, err := ioutil.ReadFile(fout.Name())
+	if err != nil {
+		t.Errorf("cannot read file, %s", err.Error())
+		return
+	}
+	if start == 0 || start > len(data) {
+		t.Errorf("unexpected ID3v2 tag size %d", start)
+		return
+	}
+	stats, err := mp3.Inspect(bytes.NewReader(data))
+	if err != nil {
+		t.Errorf("cannot inspect, %s", err.Error())
+		return
+	}
+	// the Xing tag should be right after the ID3v2 tag
+	if stats.Skipped != int64(start) {
+		t.Errorf("expected only the ID3v2 tag of %d bytes skipped, got %d", start, stats.Skipped)
+	}
+	if stats.Xing == nil || stats.Xing.Id != "Xing" || stats.Xing.Frames != stats.Frames || stats.Frames == 0 {
+		t.Errorf("unexpected Xing tag, %+v, expected frames=%d", stats.Xing, stats.Frames)
+	}
+}
+
+func Test_Encoder_CloseTwice(t *testing.T) {
+	out := new(bytes.Buffer)
+	wr, err := NewWriter(out)
+	if err != nil {
+		t.Errorf("cannot create lame writer, %s", err.Error())
+		return
+	}
+	wr.InNumChannels = 1
+	wr.InSampleRate = 16000
+	wr.OutSampleRate = 16000
+	if _, err = wr.Write(make([]byte, 4096)); err != nil {
+		t.Errorf("cannot write, %s", err.Error())
+	}
+	if err = wr.Close(); err != nil {
+		t.Errorf("cannot close, %s", err.Error())
+	}
+	size := out.Len()
+	if err = wr.Close(); err != nil || out.Len() != size {
+		t.Errorf("expected closing twice to do nothing, got %v and %d more bytes", err, out.Len()-size)
+	}
+	if !wr.lame.closed {
+		t.Errorf("expected lame released")
+	}
+	if _, err = wr.Write(make([]byte, 4096)); err != ErrClosed {
+		t.Errorf("expected ErrClosed after close, got %v", err)
+	}
+}
+
+// writes split anywhere, even in the middle of a sample, are encoded as a whole
+func Test_Encoder_SplitWrites(t *testing.T) {
+	data, err := ioutil.ReadFile("res/1chan_s16ple.raw")
+	if err != nil {
+		t.Errorf("cannot read file, %s", err.Error())
+		return
+	}
+	data = data[:len(data)/6*6+5] // 24bit stereo, with an incomplete frame at the end
+	encode := func(chunkSizes []int) ([]byte, *Writer) {
+		out := new(bytes.Buffer)
+		wr, _ := NewWriter(out)
+		wr.InSampleRate = 16000
+		wr.InBitsPerSample = 24
+		wr.InNumChannels = 2
+		wr.OutSampleRate = 16000
+		for i, p := 0, data; len(p) > 0; i++ {
+			size := chunkSizes[i%len(chunkSizes)]
+			if size > len(p) {
+				size = len(p)
+			}
+			if n, err := wr.Write(p[:size]); err != nil || n != size {
+				t.Errorf("expected %d bytes written, got %d, %v", size, n, err)
+			}
+			p = p[size:]
+		}
+		return out.Bytes(), wr
+	}
+	whole, wr := encode([]int{len(data)})
+	if wr.samplesConsumed != int64(len(data)/6) || len(wr.pending) != 5 {
+		t.Errorf("expected %d samples and 5 bytes pending, got %d and %d", len(data)/6, wr.samplesConsumed, len(wr.pending))
+	}
+	if err = wr.Close(); err != ErrIncompleteFrame || !wr.lame.closed {
+		t.Errorf("expected ErrIncompleteFrame with lame released, got %v", err)
+	}
+	split, wr := encode([]int{1, 5, 4099, 2, 6, 3})
+	if wr.samplesConsumed != int64(len(data)/6) || len(wr.pending) != 5 {
+		t.Errorf("split, expected %d samples and 5 bytes pending, got %d and %d", len(data)/6, wr.samplesConsumed, len(wr.pending))
+	}
+	wr.Close()
+	if !bytes.Equal(whole, split) {
+		t.Errorf("expected the same mp3 however the writes are split, got %d and %d bytes", len(whole), len(split))
+	}
+}
+
+// buffers are reused across writes
+func Test_Encoder_WriteAllocs(t *testing.T) {
+	tests := []struct {
+		name string
+		opts func(opts *EncodeOptions)
+	}{
+		{"int16", func(opts *EncodeOptions) {}},
+		{"float64", func(opts *EncodeOptions) {
+			opts.InSampleFormat, opts.InBitsPerSample = SAMPLE_FORMAT_FLOAT, 64
+		}},
+		{"float64 mixed", func(opts *EncodeOptions) {
+			opts.InSampleFormat, opts.InBitsPerSample, opts.InNumChannels = SAMPLE_FORMAT_FLOAT, 64, 6
+		}},
+		{"int24 resampled", func(opts *EncodeOptions) {
+			opts.InBitsPerSample, opts.InSampleRate = 24, 96000
+		}},
+	}
+	for _, test := range tests {
+		wr, err := NewWriter(ioutil.Discard)
+		if err != nil {
+			t.Errorf("%s, cannot create lame writer, %s", test.name, err.Error())
+			return
+		}
+		test.opts(&wr.EncodeOptions)
+		pcm := make([]byte, wr.InBitsPerSample/8*wr.InNumChannels*1152)
+		if _, err = wr.Write(pcm); err != nil {
+			t.Errorf("%s, cannot write, %s", test.name, err.Error())
+		}
+		if allocs := testing.AllocsPerRun(10, func() { wr.Write(pcm) }); allocs > 0 {
+			t.Errorf("%s, expected no allocation per write, got %f", test.name, allocs)
+		}
+		wr.Close()
+	}
+}
+
+// io.Copy takes ReadFrom, which encodes the same as Write
+func Test_Encoder_ReadFrom(t *testing.T) {
+	data, err := ioutil.ReadFile("res/1chan_s16ple.raw")
+	if err != nil {
+		t.Errorf("cannot read file, %s", err.Error())
+		return
+	}
+	encode := func(write func(*Writer)) []byte {
+		out := new(bytes.Buffer)
+		wr, _ := NewWriter(out)
+		wr.EncodeOptions = monoOptions()
+		write(wr)
+		wr.Close()
+		return out.Bytes()
+	}
+	expected := encode(func(wr *Writer) {
+		wr.Write(data)
+	})
+	actual := encode(func(wr *Writer) {
+		// bytes.Reader is hidden, so that io.Copy does not take its WriteTo
+		n, err := io.Copy(wr, struct{ io.Reader }{bytes.NewReader(data)})
+		if err != nil || n != int64(len(data)) {
+			t.Errorf("expected %d bytes copied, got %d, %v", len(data), n, err)
+		}
+		if size, _ := wr.inputBufSize(_READ_FROM_FRAMES); len(wr.buffers.input) != size || size%2 != 0 {
+			t.Errorf("expected a frame-aligned input buffer of %d bytes, got %d", size, len(wr.buffers.input))
+		}
+	})
+	if !bytes.Equal(expected, actual) {
+		t.Errorf("expected the same mp3 as Write, got %d and %d bytes", len(expected), len(actual))
+	}
+}
+
+// copying PCM into a writer through the generic loop of io.Copy, as before ReadFrom
+func Benchmark_Writer_CopyLoop(b *testing.B) {
+	benchmarkWriterCopy(b, func(wr *Writer) io.Writer {
+		return struct{ io.Writer }{wr}
+	})
+}
+
+func Benchmark_Writer_ReadFrom(b *testing.B) {
+	benchmarkWriterCopy(b, func(wr *Writer) io.Writer {
+		return wr
+	})
+}
+
+func benchmarkWriterCopy(b *testing.B, dst func(*Writer) io.Writer) {
+	data, err := ioutil.ReadFile("res/1chan_s16ple.raw")
+	if err != nil {
+		b.Fatalf("cannot read file, %s", err.Error())
+	}
+	wr, err := NewWriter(ioutil.Discard)
+	if err != nil {
+		b.Fatalf("cannot create lame writer, %s", err.Error())
+	}
+	defer wr.Close()
+	wr.EncodeOptions = monoOptions()
+	src := bytes.NewReader(data)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		src.Reset(data)
+		if _, err = io.Copy(dst(wr), struct{ io.Reader }{src}); err != nil {
+			b.Fatalf("cannot copy, %s", err.Error())
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package examples
+
+import (
+	"github.com/sunicy/go-lame"
+	"io"
+	"os"
+)
+
+func Mp3ToPcm(mp3FileName, pcmFileName string) {
+	mp3File, _ := os.OpenFile(mp3FileName, os.O_RDONLY, 0555)
+	pcmFile, _ := os.OpenFile(pcmFileName, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	defer pcmFile.Close()
+	rd, err := lame.NewReader(mp3File)
+	if err != nil {
+		panic("cannot create lame reader, err: " + err.Error())
+	}
+	defer rd.Close()
+	// rd.SampleRate(), rd.NumChannels() and rd.Bitrate() are available right now
+	io.Copy(pcmFile, rd) // interleaved 16bit little-endian PCM
+}
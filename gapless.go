@@ -0,0 +1,114 @@
+package lame
+
+import (
+	"errors"
+	"io"
+)
+
+// Gapless encoding of an album, e.g., a live concert or a DJ mix, into one mp3 per track
+// A single Lame encodes all the tracks. Each but the last one is flushed by EncodeFlushNogap, leaving the samples
+// buffered inside (as well as in the resamplers, if any) for the next track, which starts a new bitstream by
+// InitBitstream. Hence there is no encoder delay or padding in between, and the tracks play back without gaps.
+
+type (
+	// encodes the tracks of an album one by one, all of which share the same EncodeOptions
+	GaplessAlbumEncoder struct {
+		writer     *Writer
+		trackCount int
+		// count of the tracks encoded so far
+		current int
+	}
+
+	// a track of the album
+	AlbumTrack struct {
+		Input  io.Reader // PCM, as EncodeOptions describes; an incomplete sample at the end is dropped
+		Output io.Writer // mp3, an io.WriteSeeker to get the Xing/LAME tag of the track
+		Tags   *Tags     // ID3 tags of the track, nil if no tags
+	}
+)
+
+var (
+	ErrInvalidTrackCount = errors.New("invalid track count, expected at least 1")
+	ErrNoMoreTracks      = errors.New("all tracks of the album are encoded")
+)
+
+// create an encoder of trackCount tracks, with the given options (opts.Tags is ignored, see AlbumTrack.Tags)
+func NewGaplessAlbumEncoder(opts EncodeOptions, trackCount int) (*GaplessAlbumEncoder, error) {
+	if trackCount < 1 {
+		return nil, ErrInvalidTrackCount
+	}
+	w, err := NewWriter(nil)
+	if err != nil {
+		return nil, err
+	}
+	w.EncodeOptions = opts
+	w.Tags = nil
+	return &GaplessAlbumEncoder{
+		writer:     w,
+		trackCount: trackCount,
+	}, nil
+}
+
+// encode the next track until its input reaches EOF
+// the encoder is released after the last track
+func (e *GaplessAlbumEncoder) EncodeTrack(track AlbumTrack) (err error) {
+	if e.current >= e.trackCount {
+		return ErrNoMoreTracks
+	}
+	var w = e.writer
+	w.output = track.Output
+	w.startOffset = -1
+	w.Tags = track.Tags
+	if err = e.startTrack(); err != nil {
+		return
+	}
+	if _, err = w.ReadFrom(track.Input); err != nil {
+		return
+	}
+	w.pending = w.pending[:0] // an incomplete frame must not be carried over to the next track
+	e.current++
+	if e.current < e.trackCount {
+		return w.flush(true)
+	}
+	return w.Close()
+}
+
+// init the params for the first track, or start a new bitstream for the others
+func (e *GaplessAlbumEncoder) startTrack() (err error) {
+	var w = e.writer
+	if e.current == 0 {
+		if err = w.lame.SetNogapTotal(e.trackCount); err != nil {
+			return
+		}
+		return w.ForceUpdateParams()
+	}
+	if err = w.lame.SetNogapCurrentindex(e.current); err != nil {
+		return
+	}
+	// tags of the previous track are reset even if this one has none
+	if w.Tags != nil {
+		err = w.Tags.apply(w.lame)
+	} else {
+		err = w.lame.Id3tagInit()
+	}
+	if err != nil {
+		return
+	}
+	return w.lame.InitBitstream()
+}
+
+// encode all the tracks in order
+func (e *GaplessAlbumEncoder) EncodeAlbum(tracks []AlbumTrack) error {
+	for _, track := range tracks {
+		if err := e.EncodeTrack(track); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// release the encoder, even if not all tracks are encoded
+// calling it more than once is fine
+func (e *GaplessAlbumEncoder) Close() error {
+	return e.writer.lame.Close()
+}
@@ -0,0 +1,77 @@
+package lame
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/sunicy/go-lame/mp3"
+)
+
+func Test_GaplessAlbumEncoder(t *testing.T) {
+	data, err := ioutil.ReadFile("res/1chan_s16ple.raw")
+	if err != nil {
+		t.Errorf("cannot read file, %s", err.Error())
+		return
+	}
+	serial := new(bytes.Buffer)
+	if err = EncodeContext(context.Background(), serial, bytes.NewReader(data), monoOptions()); err != nil {
+		t.Errorf("cannot encode serially, %s", err.Error())
+		return
+	}
+
+	// uneven tracks, none of which ends on a frame boundary
+	var third = len(data) / 3 / 2 * 2
+	var inputs = [][]byte{data[:third-202], data[third-202 : third*2+1000], data[third*2+1000:]}
+	var outputs = []*bytes.Buffer{new(bytes.Buffer), new(bytes.Buffer), new(bytes.Buffer)}
+	var tracks []AlbumTrack
+	for i := range inputs {
+		tracks = append(tracks, AlbumTrack{
+			Input:  bytes.NewReader(inputs[i]),
+			Output: outputs[i],
+			Tags:   &Tags{Title: "track", Track: string('1' + byte(i))},
+		})
+	}
+	e, err := NewGaplessAlbumEncoder(monoOptions(), len(tracks))
+	if err != nil {
+		t.Errorf("cannot create encoder, %s", err.Error())
+		return
+	}
+	defer e.Close()
+	if err = e.EncodeAlbum(tracks); err != nil {
+		t.Errorf("cannot encode album, %s", err.Error())
+		return
+	}
+	if err = e.EncodeTrack(tracks[0]); err != ErrNoMoreTracks {
+		t.Errorf("expected ErrNoMoreTracks, got %v", err)
+	}
+
+	// the tracks together should be as long as the serial encode
+	serialStats, err := mp3.Inspect(serial)
+	if err != nil {
+		t.Errorf("cannot inspect, %s", err.Error())
+		return
+	}
+	var frames int
+	for i, output := range outputs {
+		stats, err := mp3.Inspect(output)
+		if err != nil {
+			t.Errorf("track %d, cannot inspect, %s", i, err.Error())
+			return
+		}
+		frames += stats.Frames
+	}
+	if diff := serialStats.Frames - frames; diff < -1 || diff > 1 {
+		t.Errorf("expected %d frames (within one), got %d", serialStats.Frames, frames)
+	}
+	if err = e.Close(); err != nil {
+		t.Errorf("cannot close twice, %s", err.Error())
+	}
+}
+
+func Test_GaplessAlbumEncoder_InvalidTrackCount(t *testing.T) {
+	if _, err := NewGaplessAlbumEncoder(monoOptions(), 0); err != ErrInvalidTrackCount {
+		t.Errorf("expected ErrInvalidTrackCount, got %v", err)
+	}
+}
@@ -0,0 +1,3 @@
+module github.com/sunicy/go-lame
+
+go 1.24
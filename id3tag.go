@@ -0,0 +1,314 @@
+package lame
+
+/*
+#cgo LDFLAGS: -lmp3lame
+
+#include <stdlib.h>
+#include "lame/lame.h"
+*/
+import "C"
+import (
+	"fmt"
+	"unicode/utf16"
+	"unsafe"
+)
+
+// ID3 tagging, ported from the id3tag_* functions of libmp3lame
+// NOTE: tags MUST BE SET BEFORE InitParams, as the ID3v2 tag is written along with it,
+// and the ID3v1 tag is appended by EncodeFlush
+
+type (
+	// which versions of ID3 tag would be written
+	Id3Version int
+
+	// ID3 tags to be embedded into the mp3
+	// empty fields are skipped
+	Tags struct {
+		Title   string `json:"title,omitempty"`
+		Artist  string `json:"artist,omitempty"`
+		Album   string `json:"album,omitempty"`
+		Year    string `json:"year,omitempty"`
+		Comment string `json:"comment,omitempty"`
+		Track   string `json:"track,omitempty"` // track number, e.g., "3", or "3/12" (ID3v2 only)
+		Genre   string `json:"genre,omitempty"` // ID3v1 genre name or number, e.g., "Podcast" or "186"; other names are kept in ID3v2 only
+
+		AlbumArt []byte `json:"album_art,omitempty"` // cover image, JPEG, PNG or GIF (ID3v2 only)
+
+		Version Id3Version `json:"version,omitempty"` // ID3_AUTO by default
+	}
+)
+
+// let us define id3 versions here
+const (
+	ID3_AUTO      Id3Version = iota // ID3v1, with ID3v2 added if the tags do not fit into ID3v1
+	ID3_V1_ONLY                     // id3tag_v1_only
+	ID3_V2_ONLY                     // id3tag_v2_only
+	ID3_V1_AND_V2                   // id3tag_add_v2
+)
+
+// apply the tags onto the given lame
+func (t *Tags) apply(l *Lame) (err error) {
+	if err = l.Id3tagInit(); err != nil {
+		return
+	}
+	switch t.Version {
+	case ID3_V1_ONLY:
+		err = l.Id3tagV1Only()
+	case ID3_V2_ONLY:
+		err = l.Id3tagV2Only()
+	case ID3_V1_AND_V2:
+		err = l.Id3tagAddV2()
+	}
+	if err != nil {
+		return
+	}
+	setters := []struct {
+		value string
+		set   func(string) error
+	}{
+		{t.Title, l.Id3tagSetTitle},
+		{t.Artist, l.Id3tagSetArtist},
+		{t.Album, l.Id3tagSetAlbum},
+		{t.Year, l.Id3tagSetYear},
+		{t.Comment, l.Id3tagSetComment},
+		{t.Track, l.Id3tagSetTrack},
+		{t.Genre, l.Id3tagSetGenre},
+	}
+	for _, setter := range setters {
+		if setter.value == "" {
+			continue
+		}
+		if err = setter.set(setter.value); err != nil {
+			return
+		}
+	}
+	if len(t.AlbumArt) > 0 {
+		return l.Id3tagSetAlbumart(t.AlbumArt)
+	}
+	return nil
+}
+
+func id3tagError(name string, retCode int) error {
+	if retCode != 0 {
+		return fmt.Errorf("cannot %s, code=%d", name, retCode)
+	}
+	return nil
+}
+
+// convert s into ISO-8859-1, which is what ID3v1 and id3tag_set_* expect
+// returns false if s contains characters out of ISO-8859-1
+func toLatin1(s string) ([]byte, bool) {
+	latin1 := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r > 0xff {
+			return nil, false
+		}
+		latin1 = append(latin1, byte(r))
+	}
+	return latin1, true
+}
+
+// convert s into the UTF-16 expected by id3tag_*_utf16, i.e., with BOM and terminated by 0
+func toUtf16(s string) []uint16 {
+	text := append([]uint16{0xfeff}, utf16.Encode([]rune(s))...)
+	return append(text, 0)
+}
+
+// a NUL-terminated C string, which MUST BE FREED by the caller
+func cLatin1(latin1 []byte) *C.char {
+	return C.CString(string(latin1))
+}
+
+// set a text frame, in ISO-8859-1 if possible (so that ID3v1 would hold it as well), or UTF-16 (ID3v2 only)
+func (l *Lame) setId3Text(name, frameId, text string, setLatin1 func(*C.char)) error {
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
+	if latin1, ok := toLatin1(text); ok {
+		cText := cLatin1(latin1)
+		defer C.free(unsafe.Pointer(cText))
+		setLatin1(cText)
+		return nil
+	}
+	cFrameId := C.CString(frameId)
+	defer C.free(unsafe.Pointer(cFrameId))
+	utf16Text := toUtf16(text)
+	return id3tagError(name, int(C.id3tag_set_textinfo_utf16(l.lgs, cFrameId, (*C.ushort)(unsafe.Pointer(&utf16Text[0])))))
+}
+
+// size of the ID3v2 tag written at the beginning of the stream automatically, 0 if none
+func (l *Lame) id3v2TagSize() int {
+	if l.checkLgs() != nil || C.lame_get_write_id3tag_automatic(l.lgs) == 0 {
+		return 0
+	}
+	return int(C.lame_get_id3v2_tag(l.lgs, nil, 0))
+}
+
+// the ID3v1 tag EncodeFlush would append, empty if none
+// EncodeFlushNogap does not append it, so it has to be written by the caller
+func (l *Lame) id3v1Tag() []byte {
+	if l.checkLgs() != nil || C.lame_get_write_id3tag_automatic(l.lgs) == 0 {
+		return nil
+	}
+	buf := make([]byte, 128)
+	size := int(C.lame_get_id3v1_tag(l.lgs, (*C.uchar)(unsafe.Pointer(&buf[0])), C.size_t(len(buf))))
+	if size > len(buf) {
+		return nil
+	}
+	return buf[:size]
+}
+
+/* MUST BE CALLED before any other id3tag functions, as it resets all the tags */
+func (l *Lame) Id3tagInit() error {
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
+	C.id3tag_init(l.lgs)
+	return nil
+}
+
+/* force addition of version 2 tag */
+func (l *Lame) Id3tagAddV2() error {
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
+	C.id3tag_add_v2(l.lgs)
+	return nil
+}
+
+/* add only a version 1 tag */
+func (l *Lame) Id3tagV1Only() error {
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
+	C.id3tag_v1_only(l.lgs)
+	return nil
+}
+
+/* add only a version 2 tag */
+func (l *Lame) Id3tagV2Only() error {
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
+	C.id3tag_v2_only(l.lgs)
+	return nil
+}
+
+/* pad version 1 tag with spaces instead of nulls */
+func (l *Lame) Id3tagSpaceV1() error {
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
+	C.id3tag_space_v1(l.lgs)
+	return nil
+}
+
+/* pad version 2 tag with extra 128 bytes */
+func (l *Lame) Id3tagPadV2() error {
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
+	C.id3tag_pad_v2(l.lgs)
+	return nil
+}
+
+/* pad version 2 tag with extra n bytes */
+func (l *Lame) Id3tagSetPad(n int) error {
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
+	C.id3tag_set_pad(l.lgs, C.size_t(n))
+	return nil
+}
+
+func (l *Lame) Id3tagSetTitle(title string) error {
+	return l.setId3Text("id3tag_set_title", "TIT2", title, func(s *C.char) { C.id3tag_set_title(l.lgs, s) })
+}
+
+func (l *Lame) Id3tagSetArtist(artist string) error {
+	return l.setId3Text("id3tag_set_artist", "TPE1", artist, func(s *C.char) { C.id3tag_set_artist(l.lgs, s) })
+}
+
+func (l *Lame) Id3tagSetAlbum(album string) error {
+	return l.setId3Text("id3tag_set_album", "TALB", album, func(s *C.char) { C.id3tag_set_album(l.lgs, s) })
+}
+
+func (l *Lame) Id3tagSetYear(year string) error {
+	return l.setId3Text("id3tag_set_year", "TYER", year, func(s *C.char) { C.id3tag_set_year(l.lgs, s) })
+}
+
+func (l *Lame) Id3tagSetComment(comment string) error {
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
+	if latin1, ok := toLatin1(comment); ok {
+		cComment := cLatin1(latin1)
+		defer C.free(unsafe.Pointer(cComment))
+		C.id3tag_set_comment(l.lgs, cComment)
+		return nil
+	}
+	cLang := C.CString("eng")
+	defer C.free(unsafe.Pointer(cLang))
+	desc, text := toUtf16(""), toUtf16(comment)
+	return id3tagError("id3tag_set_comment_utf16", int(C.id3tag_set_comment_utf16(l.lgs, cLang,
+		(*C.ushort)(unsafe.Pointer(&desc[0])), (*C.ushort)(unsafe.Pointer(&text[0])))))
+}
+
+/*
+track number, "n" or "n/total"
+numbers out of 1..255 are kept in ID3v2 only, which is not regarded as an error
+*/
+func (l *Lame) Id3tagSetTrack(track string) error {
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
+	cTrack := C.CString(track)
+	defer C.free(unsafe.Pointer(cTrack))
+	if retCode := int(C.id3tag_set_track(l.lgs, cTrack)); retCode != 0 && retCode != -1 {
+		return id3tagError("id3tag_set_track", retCode)
+	}
+	return nil
+}
+
+/*
+genre name or number of ID3v1
+unknown names are kept in ID3v2, and written as "Other" in ID3v1, which is not regarded as an error
+*/
+func (l *Lame) Id3tagSetGenre(genre string) error {
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
+	cGenre := C.CString(genre)
+	defer C.free(unsafe.Pointer(cGenre))
+	if retCode := int(C.id3tag_set_genre(l.lgs, cGenre)); retCode != 0 && retCode != -2 {
+		return id3tagError("id3tag_set_genre", retCode)
+	}
+	return nil
+}
+
+/*
+set a ID3v2 frame directly, e.g., "TCOM=Composer"
+*/
+func (l *Lame) Id3tagSetFieldvalue(fieldvalue string) error {
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
+	cFieldvalue := C.CString(fieldvalue)
+	defer C.free(unsafe.Pointer(cFieldvalue))
+	return id3tagError("id3tag_set_fieldvalue", int(C.id3tag_set_fieldvalue(l.lgs, cFieldvalue)))
+}
+
+/*
+cover image, JPEG, PNG or GIF, which would be copied by LAME
+an empty image removes the previous one
+*/
+func (l *Lame) Id3tagSetAlbumart(image []byte) error {
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
+	var cImage *C.char
+	if len(image) > 0 {
+		cImage = (*C.char)(unsafe.Pointer(&image[0]))
+	}
+	return id3tagError("id3tag_set_albumart", int(C.id3tag_set_albumart(l.lgs, cImage, C.size_t(len(image)))))
+}
@@ -0,0 +1,68 @@
+package lame
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+)
+
+func Test_ToLatin1(t *testing.T) {
+	if latin1, ok := toLatin1("Café"); !ok || !bytes.Equal(latin1, []byte{'C', 'a', 'f', 0xe9}) {
+		t.Errorf("unexpected latin1 %#v, ok=%v", latin1, ok)
+	}
+	if _, ok := toLatin1("播客"); ok {
+		t.Errorf("expected non-latin1 text to be rejected")
+	}
+}
+
+func Test_ToUtf16(t *testing.T) {
+	expected := []uint16{0xfeff, 'h', 'i', 0}
+	actual := toUtf16("hi")
+	if len(actual) != len(expected) {
+		t.Errorf("expected %#v, got %#v", expected, actual)
+		return
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf("expected %#v, got %#v", expected, actual)
+		}
+	}
+}
+
+func Test_Encoder_Tags(t *testing.T) {
+	data, err := ioutil.ReadFile("res/1chan_s16ple.raw")
+	if err != nil {
+		t.Errorf("cannot read file, %s", err.Error())
+		return
+	}
+	out := new(bytes.Buffer)
+	wr, err := NewWriter(out)
+	if err != nil {
+		t.Errorf("cannot create lame writer, %s", err.Error())
+		return
+	}
+	wr.InNumChannels = 1
+	wr.InSampleRate = 16000
+	wr.OutSampleRate = 16000
+	wr.OutMode = MODE_MONO
+	wr.Tags = &Tags{
+		Title:   "Episode 1",
+		Artist:  "播客",
+		Track:   "1/12",
+		Genre:   "Podcast",
+		Version: ID3_V1_AND_V2,
+	}
+	if _, err = wr.Write(data); err != nil {
+		t.Errorf("cannot write, %s", err.Error())
+		return
+	}
+	wr.Close()
+
+	mp3 := out.Bytes()
+	if !bytes.HasPrefix(mp3, []byte("ID3")) {
+		t.Errorf("ID3v2 tag not found")
+	}
+	if len(mp3) < 128 || !bytes.HasPrefix(mp3[len(mp3)-128:], []byte("TAG")) {
+		t.Errorf("ID3v1 tag not found")
+	}
+}
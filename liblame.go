@@ -22,6 +22,8 @@ type (
 		lgs *C.struct_lame_global_struct
 		// lame_init_params not called after some settings?
 		paramUpdated bool
+		// lame_close called?
+		closed bool
 	}
 
 	// MPEG_mode_e
@@ -75,6 +77,8 @@ var (
 	ErrUnknown            = errors.New("unknown")
 	ErrEmptyArguments     = errors.New("some arguments are empty")
 	ErrInvalidSampleRate  = errors.New("invalid sample rate, supports only 8, 12, 16, 22, 32, 44.1, 48k")
+	ErrClosed             = errors.New("lame is closed")
+	ErrUninitialized      = errors.New("uninitialized Lame struct, please create it by NewLame")
 )
 
 // create and init a lame struct
@@ -95,7 +99,9 @@ func NewLame() (l *Lame, err error) {
  * sets more internal configuration based on data provided above.
  */
 func (l *Lame) InitParams() error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	if retCode := int(C.lame_init_params(l.lgs)); retCode != 0 {
 		return ErrCannotInitParams
 	}
@@ -108,7 +114,9 @@ func (l *Lame) InitParams() error {
  * returns the length of trailing bytes
  */
 func (l *Lame) EncodeFlush() (residual []byte, err error) {
-	l.checkLgs()
+	if err = l.checkLgs(); err != nil {
+		return nil, err
+	}
 	buf := make([]byte, _SAFE_MP3_BUF_SIZE)
 	cMp3Buf := (*C.uchar)(unsafe.Pointer(&buf[0]))
 	residualSize := int(C.lame_encode_flush(l.lgs, cMp3Buf, C.int(len(buf))))
@@ -118,16 +126,107 @@ func (l *Lame) EncodeFlush() (residual []byte, err error) {
 	return buf[:residualSize], nil
 }
 
+/*
+ * NOTE: MUST BE CALLED AFTER CONVERSION OF A TRACK OTHER THAN THE LAST ONE OF A GAPLESS SEQUENCE (see SetNogapTotal)
+ * flushes the frames of the track without padding, leaving the samples buffered inside for the next track.
+ * InitBitstream MUST BE CALLED before the next track
+ */
+func (l *Lame) EncodeFlushNogap() (residual []byte, err error) {
+	if err = l.checkLgs(); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, _SAFE_MP3_BUF_SIZE)
+	cMp3Buf := (*C.uchar)(unsafe.Pointer(&buf[0]))
+	residualSize := int(C.lame_encode_flush_nogap(l.lgs, cMp3Buf, C.int(len(buf))))
+	if _, err = l.encodeError(residualSize); err != nil {
+		return
+	}
+	return buf[:residualSize], nil
+}
+
+/*
+ * NOTE: MUST BE CALLED AFTER EncodeFlushNogap, AND BEFORE CONVERSION OF THE NEXT TRACK
+ * starts a new bitstream for the next track, i.e., resets the frame count, and writes the ID3v2 tag and the Xing/LAME
+ * placeholder frame again, as InitParams cannot be called twice
+ */
+func (l *Lame) InitBitstream() error {
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
+	if !l.paramUpdated {
+		return ErrParamsNotInit
+	}
+	if retCode := int(C.lame_init_bitstream(l.lgs)); retCode != 0 {
+		return fmt.Errorf("cannot init bitstream, code=%d", retCode)
+	}
+	return nil
+}
+
+/*
+ * NOTE: MUST BE CALLED AFTER EncodeFlush
+ * returns the final Xing/LAME tag frame, containing frame count, byte count, TOC, encoder delay and padding.
+ * it is supposed to overwrite the placeholder frame at the beginning of the stream (right after the ID3v2 tag if any).
+ * returns empty if the VBR tag is disabled (SetBWriteVbrTag(0))
+ */
+func (l *Lame) GetLametagFrame() ([]byte, error) {
+	if err := l.checkLgs(); err != nil {
+		return nil, err
+	}
+	size := int(C.lame_get_lametag_frame(l.lgs, nil, 0))
+	if size == 0 {
+		return nil, nil
+	}
+	buf := make([]byte, size)
+	if written := int(C.lame_get_lametag_frame(l.lgs, (*C.uchar)(unsafe.Pointer(&buf[0])), C.size_t(len(buf)))); written > len(buf) {
+		return nil, ErrTooSmallBuffer
+	} else {
+		return buf[:written], nil
+	}
+}
+
 // bind to release the memory
 func finalizer(l *Lame) {
-	C.lame_close(l.lgs)
+	if l.lgs != nil {
+		C.lame_close(l.lgs)
+	}
+}
+
+/*
+ * release the memory right away, rather than waiting for the finalizer
+ * calling it more than once is fine. any call afterwards returns ErrClosed
+ */
+func (l *Lame) Close() error {
+	if l.lgs != nil {
+		C.lame_close(l.lgs)
+		l.lgs = nil
+	}
+	l.closed = true
+	runtime.SetFinalizer(l, nil)
+	return nil
 }
 
-// PANIC if Lame is not initialized
-func (l *Lame) checkLgs() {
+// replace lame_global_struct with a fresh one, as lame_init_params cannot be called twice
+// params MUST BE SET and initialized again
+func (l *Lame) reset() error {
+	l.Close()
+	if l.lgs = C.lame_init(); l.lgs == nil {
+		return ErrInsufficientMemory
+	}
+	l.paramUpdated = false
+	l.closed = false
+	runtime.SetFinalizer(l, finalizer)
+	return nil
+}
+
+// ErrClosed if Lame is closed, or ErrUninitialized if it is not created by NewLame
+func (l *Lame) checkLgs() error {
+	if l.closed {
+		return ErrClosed
+	}
 	if l.lgs == nil {
-		panic("uninitialized Lame struct")
+		return ErrUninitialized
 	}
+	return nil
 }
 
 /*
@@ -136,6 +235,9 @@ func (l *Lame) checkLgs() {
 // encode pcm to mp3, given buffer
 // according to LAME, there is a loose bound for the buf, len=1.25*numSamped + 7200
 func (l *Lame) EncodeInt16(dataLeft, dataRight []int16, mp3Buf []byte) (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
 	if len(mp3Buf) == 0 || len(dataLeft) == 0 || len(dataRight) == 0 {
 		return 0, ErrEmptyArguments
 	}
@@ -146,19 +248,28 @@ func (l *Lame) EncodeInt16(dataLeft, dataRight []int16, mp3Buf []byte) (int, err
 	return l.encodeError(ret)
 }
 
-// encode pcm to mp3, given buffer. same with encodeInt64, except data for left and right channels being interleaved
+// encode pcm to mp3, given buffer. same with EncodeInt16, except data for left and right channels being interleaved
 // according to LAME, there is a loose bound for the buf, len=1.25*numSamped + 7200
+// NOTE: LAME always reads the data in pairs, so it is for stereo input only
 func (l *Lame) EncodeInt16Interleaved(data []int16, mp3Buf []byte) (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
 	if len(mp3Buf) == 0 || len(data) == 0 {
 		return 0, ErrEmptyArguments
 	}
 	cMp3Buf := (*C.uchar)(unsafe.Pointer(&mp3Buf[0]))
 	cData := (*C.short)(unsafe.Pointer(&data[0]))
-	ret := int(C.lame_encode_buffer_interleaved(l.lgs, cData, C.int(len(data)), cMp3Buf, C.int(len(mp3Buf))))
+	ret := int(C.lame_encode_buffer_interleaved(l.lgs, cData, C.int(len(data)/2), cMp3Buf, C.int(len(mp3Buf))))
 	return l.encodeError(ret)
 }
 
+// encode 32bit pcm to mp3, given buffer. samples are full-scale, i.e., ranging from math.MinInt32 to math.MaxInt32
+// according to LAME, there is a loose bound for the buf, len=1.25*numSamped + 7200
 func (l *Lame) EncodeInt32(dataLeft, dataRight []int32, mp3Buf []byte) (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
 	if len(mp3Buf) == 0 || len(dataLeft) == 0 || len(dataRight) == 0 {
 		return 0, ErrEmptyArguments
 	}
@@ -169,17 +280,118 @@ func (l *Lame) EncodeInt32(dataLeft, dataRight []int32, mp3Buf []byte) (int, err
 	return l.encodeError(ret)
 }
 
-func (l *Lame) EncodeInt64(dataLeft, dataRight []int32, mp3Buf []byte) (int, error) {
+// same with EncodeInt32, except data for left and right channels being interleaved
+// according to LAME, there is a loose bound for the buf, len=1.25*numSamped + 7200
+// NOTE: LAME always reads the data in pairs, so it is for stereo input only
+func (l *Lame) EncodeInt32Interleaved(data []int32, mp3Buf []byte) (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	if len(mp3Buf) == 0 || len(data) == 0 {
+		return 0, ErrEmptyArguments
+	}
+	cMp3Buf := (*C.uchar)(unsafe.Pointer(&mp3Buf[0]))
+	cData := (*C.int)(unsafe.Pointer(&data[0]))
+	ret := int(C.lame_encode_buffer_interleaved_int(l.lgs, cData, C.int(len(data)/2), cMp3Buf, C.int(len(mp3Buf))))
+	return l.encodeError(ret)
+}
+
+// encode 64bit pcm to mp3 by lame_encode_buffer_long2, given buffer
+// samples are full-scale, i.e., ranging from math.MinInt64 to math.MaxInt64, whatever the size of C long is.
+// if it is 32bit, e.g., on Windows, samples are narrowed into a temporary buffer first
+// according to LAME, there is a loose bound for the buf, len=1.25*numSamped + 7200
+func (l *Lame) EncodeLong(dataLeft, dataRight []int64, mp3Buf []byte) (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
 	if len(mp3Buf) == 0 || len(dataLeft) == 0 || len(dataRight) == 0 {
 		return 0, ErrEmptyArguments
 	}
 	cMp3Buf := (*C.uchar)(unsafe.Pointer(&mp3Buf[0]))
-	cDataLeft := (*C.long)(unsafe.Pointer(&dataLeft[0]))
-	cDataRight := (*C.long)(unsafe.Pointer(&dataRight[0]))
+	cDataLeft, cDataRight := cLongs(dataLeft), cLongs(dataRight)
 	ret := int(C.lame_encode_buffer_long2(l.lgs, cDataLeft, cDataRight, C.int(len(dataLeft)), cMp3Buf, C.int(len(mp3Buf))))
 	return l.encodeError(ret)
 }
 
+// Deprecated: it used to take []int32, which LAME read as 64bit longs. use EncodeLong instead
+func (l *Lame) EncodeInt64(dataLeft, dataRight []int64, mp3Buf []byte) (int, error) {
+	return l.EncodeLong(dataLeft, dataRight, mp3Buf)
+}
+
+// samples as C longs, narrowed into a copy if C long is 32bit
+func cLongs(samples []int64) *C.long {
+	if C.sizeof_long == 8 {
+		return (*C.long)(unsafe.Pointer(&samples[0]))
+	}
+	narrowed := make([]C.long, len(samples))
+	for i, v := range samples {
+		narrowed[i] = C.long(v >> 32)
+	}
+	return &narrowed[0]
+}
+
+// encode IEEE float pcm to mp3, given buffer. samples are expected to be in range [-1, 1]
+// according to LAME, there is a loose bound for the buf, len=1.25*numSamped + 7200
+func (l *Lame) EncodeFloat32(dataLeft, dataRight []float32, mp3Buf []byte) (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	if len(mp3Buf) == 0 || len(dataLeft) == 0 || len(dataRight) == 0 {
+		return 0, ErrEmptyArguments
+	}
+	cMp3Buf := (*C.uchar)(unsafe.Pointer(&mp3Buf[0]))
+	cDataLeft := (*C.float)(unsafe.Pointer(&dataLeft[0]))
+	cDataRight := (*C.float)(unsafe.Pointer(&dataRight[0]))
+	ret := int(C.lame_encode_buffer_ieee_float(l.lgs, cDataLeft, cDataRight, C.int(len(dataLeft)), cMp3Buf, C.int(len(mp3Buf))))
+	return l.encodeError(ret)
+}
+
+// same with EncodeFloat32, except data for left and right channels being interleaved
+// NOTE: LAME always reads the data in pairs, so it is for stereo input only
+func (l *Lame) EncodeFloat32Interleaved(data []float32, mp3Buf []byte) (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	if len(mp3Buf) == 0 || len(data) == 0 {
+		return 0, ErrEmptyArguments
+	}
+	cMp3Buf := (*C.uchar)(unsafe.Pointer(&mp3Buf[0]))
+	cData := (*C.float)(unsafe.Pointer(&data[0]))
+	ret := int(C.lame_encode_buffer_interleaved_ieee_float(l.lgs, cData, C.int(len(data)/2), cMp3Buf, C.int(len(mp3Buf))))
+	return l.encodeError(ret)
+}
+
+// encode IEEE double pcm to mp3, given buffer. samples are expected to be in range [-1, 1]
+// according to LAME, there is a loose bound for the buf, len=1.25*numSamped + 7200
+func (l *Lame) EncodeFloat64(dataLeft, dataRight []float64, mp3Buf []byte) (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	if len(mp3Buf) == 0 || len(dataLeft) == 0 || len(dataRight) == 0 {
+		return 0, ErrEmptyArguments
+	}
+	cMp3Buf := (*C.uchar)(unsafe.Pointer(&mp3Buf[0]))
+	cDataLeft := (*C.double)(unsafe.Pointer(&dataLeft[0]))
+	cDataRight := (*C.double)(unsafe.Pointer(&dataRight[0]))
+	ret := int(C.lame_encode_buffer_ieee_double(l.lgs, cDataLeft, cDataRight, C.int(len(dataLeft)), cMp3Buf, C.int(len(mp3Buf))))
+	return l.encodeError(ret)
+}
+
+// same with EncodeFloat64, except data for left and right channels being interleaved
+// NOTE: LAME always reads the data in pairs, so it is for stereo input only
+func (l *Lame) EncodeFloat64Interleaved(data []float64, mp3Buf []byte) (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	if len(mp3Buf) == 0 || len(data) == 0 {
+		return 0, ErrEmptyArguments
+	}
+	cMp3Buf := (*C.uchar)(unsafe.Pointer(&mp3Buf[0]))
+	cData := (*C.double)(unsafe.Pointer(&data[0]))
+	ret := int(C.lame_encode_buffer_interleaved_ieee_double(l.lgs, cData, C.int(len(data)/2), cMp3Buf, C.int(len(mp3Buf))))
+	return l.encodeError(ret)
+}
+
 func (l *Lame) encodeError(ret int) (size int, err error) {
 	if ret >= 0 {
 		return ret, nil
@@ -219,7 +431,9 @@ func (l *Lame) checkSampleRate(rate int) error {
 
 // set input sample rate
 func (l *Lame) SetInSampleRate(sampleRate int) error {
-	l.checkLgs() // try to panic
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	if err := l.checkSampleRate(sampleRate); err != nil {
 		return err
 	}
@@ -227,22 +441,28 @@ func (l *Lame) SetInSampleRate(sampleRate int) error {
 }
 
 // get input sample rate
-func (l *Lame) GetInSampleRate() int {
-	l.checkLgs()
-	return int(C.lame_get_in_samplerate(l.lgs))
+func (l *Lame) GetInSampleRate() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_in_samplerate(l.lgs)), nil
 }
 
 /* number of channels in input stream. default=2  */
 // set number of channels
 func (l *Lame) SetNumChannels(numChannels int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_num_channels", int(C.lame_set_num_channels(l.lgs, C.int(numChannels))))
 }
 
 // get the number of channels
-func (l *Lame) GetNumChannels() int {
-	l.checkLgs()
-	return int(C.lame_get_in_samplerate(l.lgs))
+func (l *Lame) GetNumChannels() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_num_channels(l.lgs)), nil
 }
 
 /*
@@ -250,12 +470,16 @@ func (l *Lame) GetNumChannels() int {
   (not used by decoding routines)
 */
 func (l *Lame) SetScale(scale float32) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_scale", int( C.lame_set_scale(l.lgs, C.float(scale))))
 }
-func (l *Lame) GetScale() float32 {
-	l.checkLgs()
-	return float32(C.lame_get_scale(l.lgs))
+func (l *Lame) GetScale() (float32, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return float32(C.lame_get_scale(l.lgs)), nil
 }
 
 /*
@@ -264,7 +488,9 @@ func (l *Lame) GetScale() float32 {
   ref: https://github.com/gypified/libmp3lame/blob/master/include/lame.h#L206
 */
 func (l *Lame) SetScaleLeft(scale float32) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_scale_left", int(C.lame_set_scale_left(l.lgs, C.float(scale))))
 }
 
@@ -273,13 +499,17 @@ func (l *Lame) SetScaleLeft(scale float32) error {
   (not used by decoding routines)
 */
 func (l *Lame) SetScaleRight(scaleRight float32) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_scale_right", int(C.lame_set_scale_right(l.lgs, C.float(scaleRight))))
 }
 
-func (l *Lame) GetScaleRight() float32 {
-	l.checkLgs()
-	return float32(C.lame_get_scale_right(l.lgs))
+func (l *Lame) GetScaleRight() (float32, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return float32(C.lame_get_scale_right(l.lgs)), nil
 }
 
 /*
@@ -291,16 +521,20 @@ func (l *Lame) GetScaleRight() float32 {
   (not used by decoding routines)
 */
 func (l *Lame) SetOutSampleRate(outSampleRate int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	if err := l.checkSampleRate(outSampleRate); err != nil {
 		return err
 	}
 	return l.setterError("lame_set_out_samplerate", int(C.lame_set_out_samplerate(l.lgs, C.int(outSampleRate))))
 }
 
-func (l *Lame) GetOutSampleRate() int {
-	l.checkLgs()
-	return int(C.lame_get_out_samplerate(l.lgs))
+func (l *Lame) GetOutSampleRate() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_out_samplerate(l.lgs)), nil
 }
 
 /*  below are general control parameters
@@ -308,13 +542,17 @@ func (l *Lame) GetOutSampleRate() int {
 	set to 1 if you need LAME to ollect data for an MP3 frame analyzer
 */
 func (l *Lame) SetAnalysis(analysis int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_analysis", int(C.lame_set_analysis(l.lgs, C.int(analysis))))
 }
 
-func (l *Lame) GetAnalysis() int {
-	l.checkLgs()
-	return int(C.lame_get_analysis(l.lgs))
+func (l *Lame) GetAnalysis() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_analysis(l.lgs)), nil
 }
 
 /*
@@ -323,24 +561,32 @@ func (l *Lame) GetAnalysis() int {
   this variable must have been added by a Hungarian notation Windows programmer :-)
 */
 func (l *Lame) SetBWriteVbrTag(bWriteVbrTag int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_bWriteVbrTag", int(C.lame_set_bWriteVbrTag(l.lgs, C.int(bWriteVbrTag))))
 }
 
-func (l *Lame) GetBWriteVbrTag() int {
-	l.checkLgs()
-	return int(C.lame_get_bWriteVbrTag(l.lgs))
+func (l *Lame) GetBWriteVbrTag() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_bWriteVbrTag(l.lgs)), nil
 }
 
 /* 1=decode only.  use lame/mpglib to convert mp3/ogg to wav.  default=0 */
 func (l *Lame) SetDecodeOnly(decodeOnly int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_decode_only", int(C.lame_set_decode_only(l.lgs, C.int(decodeOnly))))
 }
 
-func (l *Lame) GetDecodeOnly() int {
-	l.checkLgs()
-	return int(C.lame_get_decode_only(l.lgs))
+func (l *Lame) GetDecodeOnly() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_decode_only(l.lgs)), nil
 }
 
 /*
@@ -352,13 +598,17 @@ func (l *Lame) GetDecodeOnly() int {
                 7     ok quality, really fast
 */
 func (l *Lame) SetQuality(quality int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_quality", int(C.lame_set_quality(l.lgs, C.int(quality))))
 }
 
-func (l *Lame) GetQuality() int {
-	l.checkLgs()
-	return int(C.lame_get_quality(l.lgs))
+func (l *Lame) GetQuality() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_quality(l.lgs)), nil
 }
 
 /*
@@ -366,12 +616,16 @@ func (l *Lame) GetQuality() int {
   default: lame picks based on compression ration and input channels
 */
 func (l *Lame) SetMode(mode Mode) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_mode", int(C.lame_set_mode(l.lgs, C.MPEG_mode(mode))))
 }
-func (l *Lame) GetMode() Mode {
-	l.checkLgs()
-	return Mode(C.lame_get_mode(l.lgs))
+func (l *Lame) GetMode() (Mode, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return Mode(C.lame_get_mode(l.lgs)), nil
 }
 
 /*
@@ -379,35 +633,47 @@ func (l *Lame) GetMode() Mode {
   default = 0 (disabled)
 */
 func (l *Lame) SetForceMs(forceMs int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_force_ms", int(C.lame_set_force_ms(l.lgs, C.int(forceMs))))
 }
 
-func (l *Lame) GetForceMs() int {
-	l.checkLgs()
-	return int(C.lame_get_force_ms(l.lgs))
+func (l *Lame) GetForceMs() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_force_ms(l.lgs)), nil
 }
 
 /* use free_format?  default = 0 (disabled) */
 func (l *Lame) SetFreeFormat(freeFormat int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_free_format", int(C.lame_set_free_format(l.lgs, C.int(freeFormat))))
 }
 
-func (l *Lame) GetFreeFormat() int {
-	l.checkLgs()
-	return int(C.lame_get_free_format(l.lgs))
+func (l *Lame) GetFreeFormat() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_free_format(l.lgs)), nil
 }
 
 /* perform ReplayGain analysis?  default = 0 (disabled) */
 func (l *Lame) SetFindReplayGain(findReplayGain int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_findReplayGain", int(C.lame_set_findReplayGain(l.lgs, C.int(findReplayGain))))
 }
 
-func (l *Lame) GetFindReplayGain() int {
-	l.checkLgs()
-	return int(C.lame_get_findReplayGain(l.lgs))
+func (l *Lame) GetFindReplayGain() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_findReplayGain(l.lgs)), nil
 }
 
 /* decode on the fly. Search for the peak sample. If the ReplayGain
@@ -415,64 +681,93 @@ func (l *Lame) GetFindReplayGain() int {
  * stream. default = 0 (disabled)
  * NOTE: if this option is set the build-in decoder should not be used */
 func (l *Lame) SetDecodeOnTheFly(decode_on_the_fly int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_decode_on_the_fly", int(C.lame_set_decode_on_the_fly(l.lgs, C.int(decode_on_the_fly))))
 }
 
-func (l *Lame) GetDecodeOnTheFly() int {
-	l.checkLgs()
-	return int(C.lame_get_decode_on_the_fly(l.lgs))
+func (l *Lame) GetDecodeOnTheFly() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_decode_on_the_fly(l.lgs)), nil
 }
 
 /* counters for gapless encoding */
 func (l *Lame) SetNogapTotal(nogapTotal int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_nogap_total", int(C.lame_set_nogap_total(l.lgs, C.int(nogapTotal))))
 }
 
-func (l *Lame) GetNogapTotal() int {
-	l.checkLgs()
-	return int(C.lame_get_nogap_total(l.lgs))
+func (l *Lame) GetNogapTotal() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_nogap_total(l.lgs)), nil
 }
 
+// unlike other setters, it is meant to be called between the tracks, so the params remain initialized
 func (l *Lame) SetNogapCurrentindex(nogapCurrentindex int) error {
-	l.checkLgs()
-	return l.setterError("lame_set_nogap_currentindex", int(C.lame_set_nogap_currentindex(l.lgs, C.int(nogapCurrentindex))))
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
+	if retCode := int(C.lame_set_nogap_currentindex(l.lgs, C.int(nogapCurrentindex))); retCode != 0 {
+		return fmt.Errorf("cannot lame_set_nogap_currentindex, code=%d", retCode)
+	}
+	return nil
 }
 
-func (l *Lame) GetNogapCurrentindex() int {
-	l.checkLgs()
-	return int(C.lame_get_nogap_currentindex(l.lgs))
+func (l *Lame) GetNogapCurrentindex() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_nogap_currentindex(l.lgs)), nil
 }
 
 /* set one of brate compression ratio.  default is compression ratio of 11.  */
 func (l *Lame) SetBrate(brate int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_brate", int(C.lame_set_brate(l.lgs, C.int(brate))))
 }
 
-func (l *Lame) GetBrate() int {
-	l.checkLgs()
-	return int(C.lame_get_brate(l.lgs))
+func (l *Lame) GetBrate() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_brate(l.lgs)), nil
 }
 
 func (l *Lame) SetCompressionRatio(compressionRatio float32) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_compression_ratio", int(C.lame_set_compression_ratio(l.lgs, C.float(compressionRatio))))
 }
 
-func (l *Lame) GetCompressionRatio() float32 {
-	l.checkLgs()
-	return float32(C.lame_get_compression_ratio(l.lgs))
+func (l *Lame) GetCompressionRatio() (float32, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return float32(C.lame_get_compression_ratio(l.lgs)), nil
 }
 
-func (l *Lame) SetPreset(preset int) error {
-	l.checkLgs()
+/* one of the Preset constants, or an ABR bitrate (8-320 kbps) */
+func (l *Lame) SetPreset(preset Preset) error {
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_preset", int(C.lame_set_preset(l.lgs, C.int(preset))))
 }
 
 func (l *Lame) SetAsmOptimizations(optim AsmOptimizations, mode int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_asm_optimizations", int(C.lame_set_asm_optimizations(l.lgs, C.int(optim), C.int(mode))))
 }
 
@@ -481,57 +776,77 @@ func (l *Lame) SetAsmOptimizations(optim AsmOptimizations, mode int) error {
  ***********************************************************************/
 /* mark as copyright.  default=0 */
 func (l *Lame) SetCopyright(copyright int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_copyright", int(C.lame_set_copyright(l.lgs, C.int(copyright))))
 }
 
-func (l *Lame) GetCopyright() int {
-	l.checkLgs()
-	return int(C.lame_get_copyright(l.lgs))
+func (l *Lame) GetCopyright() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_copyright(l.lgs)), nil
 }
 
 /* mark as original.  default=1 */
 func (l *Lame) SetOriginal(original int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_original", int(C.lame_set_original(l.lgs, C.int(original))))
 }
 
-func (l *Lame) GetOriginal() int {
-	l.checkLgs()
-	return int(C.lame_get_original(l.lgs))
+func (l *Lame) GetOriginal() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_original(l.lgs)), nil
 }
 
 /* error_protection.  Use 2 bytes from each frame for CRC checksum. default=0 */
 func (l *Lame) SetErrorProtection(errorProtection int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_error_protection", int(C.lame_set_error_protection(l.lgs, C.int(errorProtection))))
 }
 
-func (l *Lame) GetErrorProtection() int {
-	l.checkLgs()
-	return int(C.lame_get_error_protection(l.lgs))
+func (l *Lame) GetErrorProtection() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_error_protection(l.lgs)), nil
 }
 
 /* MP3 'private extension' bit  Meaningless.  default=0 */
 func (l *Lame) SetExtension(extension int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_extension", int(C.lame_set_extension(l.lgs, C.int(extension))))
 }
 
-func (l *Lame) GetExtension() int {
-	l.checkLgs()
-	return int(C.lame_get_extension(l.lgs))
+func (l *Lame) GetExtension() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_extension(l.lgs)), nil
 }
 
 /* enforce strict ISO compliance.  default=0 */
 func (l *Lame) SetStrictISO(strictISO int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_strict_ISO", int(C.lame_set_strict_ISO(l.lgs, C.int(strictISO))))
 }
 
-func (l *Lame) GetStrictISO() int {
-	l.checkLgs()
-	return int(C.lame_get_strict_ISO(l.lgs))
+func (l *Lame) GetStrictISO() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_strict_ISO(l.lgs)), nil
 }
 
 /********************************************************************
@@ -540,154 +855,211 @@ func (l *Lame) GetStrictISO() int {
 
 /* disable the bit reservoir. For testing only. default=0 */
 func (l *Lame) SetDisableReservoir(disable_reservoir int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_disable_reservoir", int(C.lame_set_disable_reservoir(l.lgs, C.int(disable_reservoir))))
 }
 
-func (l *Lame) GetDisableReservoir() int {
-	l.checkLgs()
-	return int(C.lame_get_disable_reservoir(l.lgs))
+func (l *Lame) GetDisableReservoir() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_disable_reservoir(l.lgs)), nil
 }
 
 /* select a different "best quantization" function. default=0  */
 func (l *Lame) SetQuantComp(quantComp int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_quant_comp", int(C.lame_set_quant_comp(l.lgs, C.int(quantComp))))
 }
 
-func (l *Lame) GetQuantComp() int {
-	l.checkLgs()
-	return int(C.lame_get_quant_comp(l.lgs))
+func (l *Lame) GetQuantComp() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_quant_comp(l.lgs)), nil
 }
 
 func (l *Lame) SetQuantCompShort(quantCompShort int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_quant_comp_short", int(C.lame_set_quant_comp_short(l.lgs, C.int(quantCompShort))))
 }
 
-func (l *Lame) GetQuantCompShort() int {
-	l.checkLgs()
-	return int(C.lame_get_quant_comp_short(l.lgs))
+func (l *Lame) GetQuantCompShort() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_quant_comp_short(l.lgs)), nil
 }
 
 func (l *Lame) SetExperimentalX(experimentalX int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_experimentalX", int(C.lame_set_experimentalX(l.lgs, C.int(experimentalX))))
 }
 
 /* compatibility*/
-func (l *Lame) GetExperimentalX() int {
-	l.checkLgs()
-	return int(C.lame_get_experimentalX(l.lgs))
+func (l *Lame) GetExperimentalX() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_experimentalX(l.lgs)), nil
 }
 
 /* another experimental option.  for testing only */
 func (l *Lame) SetExperimentalY(experimentalY int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_experimentalY", int(C.lame_set_experimentalY(l.lgs, C.int(experimentalY))))
 }
 
-func (l *Lame) GetExperimentalY() int {
-	l.checkLgs()
-	return int(C.lame_get_experimentalY(l.lgs))
+func (l *Lame) GetExperimentalY() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_experimentalY(l.lgs)), nil
 }
 
 /* another experimental option.  for testing only */
 func (l *Lame) SetExperimentalZ(experimentalZ int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_experimentalZ", int(C.lame_set_experimentalZ(l.lgs, C.int(experimentalZ))))
 }
 
-func (l *Lame) GetExperimentalZ() int {
-	l.checkLgs()
-	return int(C.lame_get_experimentalZ(l.lgs))
+func (l *Lame) GetExperimentalZ() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_experimentalZ(l.lgs)), nil
 }
 
 /* Naoki's psycho acoustic model.  default=0 */
 func (l *Lame) SetExpNspsytune(expNspsytune int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_exp_nspsytune", int(C.lame_set_exp_nspsytune(l.lgs, C.int(expNspsytune))))
 }
 
-func (l *Lame) GetExpNspsytune() int {
-	l.checkLgs()
-	return int(C.lame_get_exp_nspsytune(l.lgs))
+func (l *Lame) GetExpNspsytune() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_exp_nspsytune(l.lgs)), nil
 }
 
 // void lame_set_msfix(lame_global_flags *, double);
-func (l *Lame) SetMsfix(msfix float32) {
-	l.checkLgs()
+func (l *Lame) SetMsfix(msfix float32) error {
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	C.lame_set_msfix(l.lgs, C.double(msfix))
+	return nil
 }
 
-func (l *Lame) GetMsfix() float32 {
-	l.checkLgs()
-	return float32(C.lame_get_msfix(l.lgs))
+func (l *Lame) GetMsfix() (float32, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return float32(C.lame_get_msfix(l.lgs)), nil
 }
 
 /* VBR stuff */
 /* Types of VBR.  default = VBR_OFF = CBR */
 func (l *Lame) SetVBR(vbr VBRMode) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_VBR", int(C.lame_set_VBR(l.lgs, C.vbr_mode(vbr))))
 }
 
-func (l *Lame) GetVBR() VBRMode {
-	l.checkLgs()
-	return VBRMode(C.lame_get_VBR(l.lgs))
+func (l *Lame) GetVBR() (VBRMode, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return VBRMode(C.lame_get_VBR(l.lgs)), nil
 }
 
 /* VBR quality level.  0=highest  9=lowest  */
 func (l *Lame) SetVBRQ(VBR_q int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_VBR_q", int(C.lame_set_VBR_q(l.lgs, C.int(VBR_q))))
 }
 
-func (l *Lame) GetVBRQ() int {
-	l.checkLgs()
-	return int(C.lame_get_VBR_q(l.lgs))
+func (l *Lame) GetVBRQ() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_VBR_q(l.lgs)), nil
 }
 
 /* VBR quality level.  0=highest  9=lowest, Range [0,...,10[  */
 func (l *Lame) SetVBRQuality(VBRQuality float32) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_VBR_quality", int(C.lame_set_VBR_quality(l.lgs, C.float(VBRQuality))))
 }
 
-func (l *Lame) GetVBRQuality() float32 {
-	l.checkLgs()
-	return float32(C.lame_get_VBR_quality(l.lgs))
+func (l *Lame) GetVBRQuality() (float32, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return float32(C.lame_get_VBR_quality(l.lgs)), nil
 }
 
 /* Ignored except for VBR=vbr_abr (ABR mode) */
 func (l *Lame) SetVBRMeanBitrateKbps(VBRMeanBitrateKbps int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_VBR_mean_bitrate_kbps", int(C.lame_set_VBR_mean_bitrate_kbps(l.lgs, C.int(VBRMeanBitrateKbps))))
 }
 
-func (l *Lame) GetVBRMeanBitrateKbps() int {
-	l.checkLgs()
-	return int(C.lame_get_VBR_mean_bitrate_kbps(l.lgs))
+func (l *Lame) GetVBRMeanBitrateKbps() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_VBR_mean_bitrate_kbps(l.lgs)), nil
 }
 
 func (l *Lame) SetVBRMinBitrateKbps(VBRMinBitrateKbps int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_VBR_min_bitrate_kbps", int(C.lame_set_VBR_min_bitrate_kbps(l.lgs, C.int(VBRMinBitrateKbps))))
 }
 
-func (l *Lame) GetVBRMinBitrateKbps() int {
-	l.checkLgs()
-	return int(C.lame_get_VBR_min_bitrate_kbps(l.lgs))
+func (l *Lame) GetVBRMinBitrateKbps() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_VBR_min_bitrate_kbps(l.lgs)), nil
 }
 
 func (l *Lame) SetVBRMaxBitrateKbps(VBRMaxBitrateKbps int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_VBR_max_bitrate_kbps", int(C.lame_set_VBR_max_bitrate_kbps(l.lgs, C.int(VBRMaxBitrateKbps))))
 }
 
-func (l *Lame) GetVBRMaxBitrateKbps() int {
-	l.checkLgs()
-	return int(C.lame_get_VBR_max_bitrate_kbps(l.lgs))
+func (l *Lame) GetVBRMaxBitrateKbps() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_VBR_max_bitrate_kbps(l.lgs)), nil
 }
 
 /*
@@ -695,135 +1067,183 @@ func (l *Lame) GetVBRMaxBitrateKbps() int {
   analog silence
 */
 func (l *Lame) SetVBRHardMin(VBRHardMin int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_VBR_hard_min", int(C.lame_set_VBR_hard_min(l.lgs, C.int(VBRHardMin))))
 }
 
-func (l *Lame) GetVBRHardMin() int {
-	l.checkLgs()
-	return int(C.lame_get_VBR_hard_min(l.lgs))
+func (l *Lame) GetVBRHardMin() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_VBR_hard_min(l.lgs)), nil
 }
 
 /* filtering... */
 /* freq in Hz to apply lowpass. Default = 0 = lame chooses.  -1 = disabled */
 func (l *Lame) SetLowpassfreq(lowpassfreq int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_lowpassfreq", int(C.lame_set_lowpassfreq(l.lgs, C.int(lowpassfreq))))
 }
 
-func (l *Lame) GetLowpassfreq() int {
-	l.checkLgs()
-	return int(C.lame_get_lowpassfreq(l.lgs))
+func (l *Lame) GetLowpassfreq() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_lowpassfreq(l.lgs)), nil
 }
 
 /* width of transition band, in Hz.  Default = one polyphase filter band */
 func (l *Lame) SetLowpasswidth(lowpasswidth int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_lowpasswidth", int(C.lame_set_lowpasswidth(l.lgs, C.int(lowpasswidth))))
 }
 
-func (l *Lame) GetLowpasswidth() int {
-	l.checkLgs()
-	return int(C.lame_get_lowpasswidth(l.lgs))
+func (l *Lame) GetLowpasswidth() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_lowpasswidth(l.lgs)), nil
 }
 
 /* freq in Hz to apply highpass. Default = 0 = lame chooses.  -1 = disabled */
 func (l *Lame) SetHighpassfreq(highpassfreq int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_highpassfreq", int(C.lame_set_highpassfreq(l.lgs, C.int(highpassfreq))))
 }
 
-func (l *Lame) GetHighpassfreq() int {
-	l.checkLgs()
-	return int(C.lame_get_highpassfreq(l.lgs))
+func (l *Lame) GetHighpassfreq() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_highpassfreq(l.lgs)), nil
 }
 
 /* width of transition band, in Hz.  Default = one polyphase filter band */
 func (l *Lame) SetHighpasswidth(highpasswidth int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_highpasswidth", int(C.lame_set_highpasswidth(l.lgs, C.int(highpasswidth))))
 }
 
-func (l *Lame) GetHighpasswidth() int {
-	l.checkLgs()
-	return int(C.lame_get_highpasswidth(l.lgs))
+func (l *Lame) GetHighpasswidth() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_highpasswidth(l.lgs)), nil
 }
 
 /* only use ATH for masking */
 func (l *Lame) SetATHonly(ATHonly int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_ATHonly", int(C.lame_set_ATHonly(l.lgs, C.int(ATHonly))))
 }
 
-func (l *Lame) GetATHonly() int {
-	l.checkLgs()
-	return int(C.lame_get_ATHonly(l.lgs))
+func (l *Lame) GetATHonly() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_ATHonly(l.lgs)), nil
 }
 
 /* only use ATH for short blocks */
 func (l *Lame) SetATHshort(ATHshort int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_ATHshort", int(C.lame_set_ATHshort(l.lgs, C.int(ATHshort))))
 }
 
-func (l *Lame) GetATHshort() int {
-	l.checkLgs()
-	return int(C.lame_get_ATHshort(l.lgs))
+func (l *Lame) GetATHshort() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_ATHshort(l.lgs)), nil
 }
 
 /* disable ATH */
 func (l *Lame) SetNoATH(noATH int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_noATH", int(C.lame_set_noATH(l.lgs, C.int(noATH))))
 }
 
-func (l *Lame) GetNoATH() int {
-	l.checkLgs()
-	return int(C.lame_get_noATH(l.lgs))
+func (l *Lame) GetNoATH() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_noATH(l.lgs)), nil
 }
 
 /* select ATH formula */
 func (l *Lame) SetATHtype(ATHtype int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_ATHtype", int(C.lame_set_ATHtype(l.lgs, C.int(ATHtype))))
 }
 
-func (l *Lame) GetATHtype() int {
-	l.checkLgs()
-	return int(C.lame_get_ATHtype(l.lgs))
+func (l *Lame) GetATHtype() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_ATHtype(l.lgs)), nil
 }
 
 /* lower ATH by this many db */
 func (l *Lame) SetATHlower(ATHlower float32) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_ATHlower", int(C.lame_set_ATHlower(l.lgs, C.float(ATHlower))))
 }
 
-func (l *Lame) GetATHlower() float32 {
-	l.checkLgs()
-	return float32(C.lame_get_ATHlower(l.lgs))
+func (l *Lame) GetATHlower() (float32, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return float32(C.lame_get_ATHlower(l.lgs)), nil
 }
 
 /* select ATH adaptive adjustment type */
 func (l *Lame) SetAthaaType(athaaType int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_athaa_type", int(C.lame_set_athaa_type(l.lgs, C.int(athaaType))))
 }
 
-func (l *Lame) GetAthaaType() int {
-	l.checkLgs()
-	return int(C.lame_get_athaa_type(l.lgs))
+func (l *Lame) GetAthaaType() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_athaa_type(l.lgs)), nil
 }
 
 /* adjust (in dB) the point below which adaptive ATH level adjustment occurs */
 func (l *Lame) SetAthaaSensitivity(athaaSensitivity float32) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_athaa_sensitivity", int(C.lame_set_athaa_sensitivity(l.lgs, C.float(athaaSensitivity))))
 }
 
-func (l *Lame) GetAthaaSensitivity() float32 {
-	l.checkLgs()
-	return float32(C.lame_get_athaa_type(l.lgs))
+func (l *Lame) GetAthaaSensitivity() (float32, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return float32(C.lame_get_athaa_sensitivity(l.lgs)), nil
 }
 
 /*
@@ -831,57 +1251,77 @@ func (l *Lame) GetAthaaSensitivity() float32 {
   default: 0 for jstereo, 1 for stereo
 */
 func (l *Lame) SetAllowDiffShort(allowDiffShort int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_allow_diff_short", int(C.lame_set_allow_diff_short(l.lgs, C.int(allowDiffShort))))
 }
 
-func (l *Lame) GetAllowDiffShort() int {
-	l.checkLgs()
-	return int(C.lame_get_allow_diff_short(l.lgs))
+func (l *Lame) GetAllowDiffShort() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_allow_diff_short(l.lgs)), nil
 }
 
 /* use temporal masking effect (default = 1) */
 func (l *Lame) SetUseTemporal(useTemporal int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_useTemporal", int(C.lame_set_useTemporal(l.lgs, C.int(useTemporal))))
 }
 
-func (l *Lame) GetUseTemporal() int {
-	l.checkLgs()
-	return int(C.lame_get_useTemporal(l.lgs))
+func (l *Lame) GetUseTemporal() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_useTemporal(l.lgs)), nil
 }
 
 /* use temporal masking effect (default = 1) */
 func (l *Lame) SetInterChRatio(interChRatio float32) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_interChRatio", int(C.lame_set_interChRatio(l.lgs, C.float(interChRatio))))
 }
 
-func (l *Lame) GetInterChRatio() float32 {
-	l.checkLgs()
-	return float32(C.lame_get_interChRatio(l.lgs))
+func (l *Lame) GetInterChRatio() (float32, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return float32(C.lame_get_interChRatio(l.lgs)), nil
 }
 
 /* disable short blocks */
 func (l *Lame) SetNoShortBlocks(noShortBlocks int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_no_short_blocks", int(C.lame_set_no_short_blocks(l.lgs, C.int(noShortBlocks))))
 }
 
-func (l *Lame) GetNoShortBlocks() int {
-	l.checkLgs()
-	return int(C.lame_get_no_short_blocks(l.lgs))
+func (l *Lame) GetNoShortBlocks() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_no_short_blocks(l.lgs)), nil
 }
 
 /* force short blocks */
 func (l *Lame) SetForceShortBlocks(forceShortBlocks int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_force_short_blocks", int(C.lame_set_force_short_blocks(l.lgs, C.int(forceShortBlocks))))
 }
 
-func (l *Lame) GetForceShortBlocks() int {
-	l.checkLgs()
-	return int(C.lame_get_force_short_blocks(l.lgs))
+func (l *Lame) GetForceShortBlocks() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_force_short_blocks(l.lgs)), nil
 }
 
 /* Input PCM is emphased PCM (for instance from one of the rarely
@@ -889,25 +1329,33 @@ func (l *Lame) GetForceShortBlocks() int {
    psycho does not take it into account, and last but not least many decoders
    ignore these bits */
 func (l *Lame) SetEmphasis(emphasis int) error {
-	l.checkLgs()
+	if err := l.checkLgs(); err != nil {
+		return err
+	}
 	return l.setterError("lame_set_emphasis", int(C.lame_set_emphasis(l.lgs, C.int(emphasis))))
 }
 
-func (l *Lame) GetEmphasis() int {
-	l.checkLgs()
-	return int(C.lame_get_emphasis(l.lgs))
+func (l *Lame) GetEmphasis() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_emphasis(l.lgs)), nil
 }
 
 /* mp3 version  0=MPEG-2  1=MPEG-1  (2=MPEG-2.5)     */
-func (l *Lame) GetVersion() int {
-	l.checkLgs()
-	return int(C.lame_get_version(l.lgs))
+func (l *Lame) GetVersion() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_version(l.lgs)), nil
 }
 
 /* encoder delay   */
-func (l *Lame) GetEncoderDelay() int {
-	l.checkLgs()
-	return int(C.lame_get_encoder_delay(l.lgs))
+func (l *Lame) GetEncoderDelay() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_encoder_delay(l.lgs)), nil
 }
 
 /*
@@ -916,77 +1364,99 @@ func (l *Lame) GetEncoderDelay() int {
   call to lame_encoder_flush().  Before lame_encoder_flush() has
   been called, the value of encoder_padding = 0.
 */
-func (l *Lame) GetEncoderPadding() int {
-	l.checkLgs()
-	return int(C.lame_get_encoder_padding(l.lgs))
+func (l *Lame) GetEncoderPadding() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_encoder_padding(l.lgs)), nil
 }
 
 /* size of MPEG frame */
-func (l *Lame) GetFramesize() int {
-	l.checkLgs()
-	return int(C.lame_get_framesize(l.lgs))
+func (l *Lame) GetFramesize() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_framesize(l.lgs)), nil
 }
 
 /* number of PCM samples buffered, but not yet encoded to mp3 data. */
-func (l *Lame) GetMfSamplesToEncode() int {
-	l.checkLgs()
-	return int(C.lame_get_mf_samples_to_encode(l.lgs))
+func (l *Lame) GetMfSamplesToEncode() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_mf_samples_to_encode(l.lgs)), nil
 }
 
 /*
   size (bytes) of mp3 data buffered, but not yet encoded.
   ref: https://github.com/gypified/libmp3lame/blob/master/include/lame.h#L594
 */
-func (l *Lame) GetSizeMp3buffer() int {
-	l.checkLgs()
-	return int(C.lame_get_size_mp3buffer(l.lgs))
+func (l *Lame) GetSizeMp3buffer() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_size_mp3buffer(l.lgs)), nil
 }
 
 /* number of frames encoded so far */
-func (l *Lame) GetFrameNum() int {
-	l.checkLgs()
-	return int(C.lame_get_frameNum(l.lgs))
+func (l *Lame) GetFrameNum() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_frameNum(l.lgs)), nil
 }
 
 /*
   lame's estimate of the total number of frames to be encoded
    only valid if calling program set num_samples
 */
-func (l *Lame) GetTotalframes() int {
-	l.checkLgs()
-	return int(C.lame_get_totalframes(l.lgs))
+func (l *Lame) GetTotalframes() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_totalframes(l.lgs)), nil
 }
 
 /* RadioGain value. Multiplied by 10 and rounded to the nearest. */
-func (l *Lame) GetRadioGain() int {
-	l.checkLgs()
-	return int(C.lame_get_RadioGain(l.lgs))
+func (l *Lame) GetRadioGain() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_RadioGain(l.lgs)), nil
 }
 
 /* AudiophileGain value. Multipled by 10 and rounded to the nearest. */
-func (l *Lame) GetAudiophileGain() int {
-	l.checkLgs()
-	return int(C.lame_get_AudiophileGain(l.lgs))
+func (l *Lame) GetAudiophileGain() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_AudiophileGain(l.lgs)), nil
 }
 
 /* the peak sample */
-func (l *Lame) GetPeakSample() float32 {
-	l.checkLgs()
-	return float32(C.lame_get_PeakSample(l.lgs))
+func (l *Lame) GetPeakSample() (float32, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return float32(C.lame_get_PeakSample(l.lgs)), nil
 }
 
 /* Gain change required for preventing clipping.
    ref: https://github.com/gypified/libmp3lame/blob/master/include/lame.h#L623
 */
-func (l *Lame) GetNoclipGainChange() int {
-	l.checkLgs()
-	return int(C.lame_get_noclipGainChange(l.lgs))
+func (l *Lame) GetNoclipGainChange() (int, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return int(C.lame_get_noclipGainChange(l.lgs)), nil
 }
 
 /*
  * ref: https://github.com/gypified/libmp3lame/blob/master/include/lame.h#L623
  */
-func (l *Lame) GetNoclipScale() float32 {
-	l.checkLgs()
-	return float32(C.lame_get_noclipScale(l.lgs))
+func (l *Lame) GetNoclipScale() (float32, error) {
+	if err := l.checkLgs(); err != nil {
+		return 0, err
+	}
+	return float32(C.lame_get_noclipScale(l.lgs)), nil
 }
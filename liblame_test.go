@@ -5,6 +5,9 @@ import (
 	"os"
 	"io/ioutil"
 	"fmt"
+	"bytes"
+	"encoding/binary"
+	"math"
 )
 
 func Test_LibLame_Full(t *testing.T) {
@@ -41,3 +44,180 @@ func Test_LibLame_Full(t *testing.T) {
 }
 
 
+
+func Test_LibLame_Close(t *testing.T) {
+	lame, err := NewLame()
+	if err != nil {
+		t.Errorf("cannot create lame: %s", err.Error())
+		return
+	}
+	for i := 0; i < 2; i++ {
+		if err = lame.Close(); err != nil {
+			t.Errorf("cannot close, %s", err.Error())
+		}
+	}
+	if !lame.closed || lame.lgs != nil {
+		t.Errorf("expected lame released")
+	}
+	if err = lame.SetQuality(2); err != ErrClosed {
+		t.Errorf("expected ErrClosed on setter, got %v", err)
+	}
+	if err = lame.InitParams(); err != ErrClosed {
+		t.Errorf("expected ErrClosed on InitParams, got %v", err)
+	}
+	if _, err = lame.EncodeInt16([]int16{1}, []int16{1}, make([]byte, 7200)); err != ErrClosed {
+		t.Errorf("expected ErrClosed on encode, got %v", err)
+	}
+	if _, err = lame.EncodeFlush(); err != ErrClosed {
+		t.Errorf("expected ErrClosed on flush, got %v", err)
+	}
+	if _, err = lame.GetQuality(); err != ErrClosed {
+		t.Errorf("expected ErrClosed on getter, got %v", err)
+	}
+	if err = lame.Id3tagInit(); err != ErrClosed {
+		t.Errorf("expected ErrClosed on id3tag, got %v", err)
+	}
+}
+
+func Test_LibLame_Uninitialized(t *testing.T) {
+	var lame Lame
+	if err := lame.SetQuality(2); err != ErrUninitialized {
+		t.Errorf("expected ErrUninitialized on setter, got %v", err)
+	}
+	if _, err := lame.GetQuality(); err != ErrUninitialized {
+		t.Errorf("expected ErrUninitialized on getter, got %v", err)
+	}
+	if err := lame.SetMsfix(1); err != ErrUninitialized {
+		t.Errorf("expected ErrUninitialized on SetMsfix, got %v", err)
+	}
+	if _, err := lame.EncodeInt16([]int16{1}, []int16{1}, make([]byte, 7200)); err != ErrUninitialized {
+		t.Errorf("expected ErrUninitialized on encode, got %v", err)
+	}
+	if err := lame.Id3tagSetTitle("title"); err != ErrUninitialized {
+		t.Errorf("expected ErrUninitialized on id3tag, got %v", err)
+	}
+	if err := lame.Close(); err != nil {
+		t.Errorf("expected closing an uninitialized lame to be fine, got %v", err)
+	}
+}
+
+func Test_LibLame_GetNumChannels(t *testing.T) {
+	lame, err := NewLame()
+	if err != nil {
+		t.Errorf("cannot create lame: %s", err.Error())
+		return
+	}
+	defer lame.Close()
+	lame.SetInSampleRate(16000)
+	lame.SetNumChannels(1)
+	if numChannels, err := lame.GetNumChannels(); err != nil || numChannels != 1 {
+		t.Errorf("expected 1 channel, got %d, %v", numChannels, err)
+	}
+}
+
+// encode stereo 16bit samples by encode, widened as it requires, then decode the mp3 back into 16bit samples
+func roundTrip(t *testing.T, name string, samples []int16, encode func(*Lame, []int16, []byte) (int, error)) ([]byte, []int16) {
+	lame, err := NewLame()
+	if err != nil {
+		t.Errorf("%s, cannot create lame: %s", name, err.Error())
+		return nil, nil
+	}
+	defer lame.Close()
+	lame.SetNumChannels(2)
+	lame.SetInSampleRate(44100)
+	lame.SetOutSampleRate(44100)
+	lame.SetMode(MODE_STEREO)
+	lame.InitParams()
+	buf := make([]byte, mp3BufSize(len(samples)))
+	size, err := encode(lame, samples, buf)
+	if err != nil {
+		t.Errorf("%s, cannot encode, %s", name, err.Error())
+		return nil, nil
+	}
+	residual, _ := lame.EncodeFlush()
+	mp3 := append(buf[:size], residual...)
+
+	rd, err := NewReader(bytes.NewReader(mp3))
+	if err != nil {
+		t.Errorf("%s, cannot create decoder, %s", name, err.Error())
+		return mp3, nil
+	}
+	defer rd.Close()
+	pcm, err := ioutil.ReadAll(rd)
+	if err != nil || rd.NumChannels() != 2 {
+		t.Errorf("%s, cannot decode into stereo, %d channels, %v", name, rd.NumChannels(), err)
+		return mp3, nil
+	}
+	decoded := make([]int16, len(pcm)/2)
+	for i := range decoded {
+		decoded[i] = int16(binary.LittleEndian.Uint16(pcm[i*2:]))
+	}
+	return mp3, decoded
+}
+
+// 64bit and interleaved 32bit samples are encoded as 16bit ones are, and decoded back
+func Test_LibLame_WideSamples(t *testing.T) {
+	// a second of 440Hz on the left, and 880Hz of half the amplitude on the right
+	samples := make([]int16, 44100*2)
+	for i := 0; i < len(samples)/2; i++ {
+		samples[i*2] = int16(16000 * math.Sin(2*math.Pi*440*float64(i)/44100))
+		samples[i*2+1] = int16(8000 * math.Sin(2*math.Pi*880*float64(i)/44100))
+	}
+	split := func(samples []int16) (left, right []int16) {
+		left, right = make([]int16, len(samples)/2), make([]int16, len(samples)/2)
+		for i := range left {
+			left[i], right[i] = samples[i*2], samples[i*2+1]
+		}
+		return left, right
+	}
+	expectedMp3, expected := roundTrip(t, "int16", samples, func(l *Lame, s []int16, buf []byte) (int, error) {
+		left, right := split(s)
+		return l.EncodeInt16(left, right, buf)
+	})
+	tests := []struct {
+		name   string
+		encode func(*Lame, []int16, []byte) (int, error)
+	}{
+		{"long", func(l *Lame, s []int16, buf []byte) (int, error) {
+			left, right := split(s)
+			wideLeft, wideRight := make([]int64, len(left)), make([]int64, len(right))
+			for i := range left {
+				wideLeft[i], wideRight[i] = int64(left[i])<<48, int64(right[i])<<48
+			}
+			return l.EncodeLong(wideLeft, wideRight, buf)
+		}},
+		{"int32 interleaved", func(l *Lame, s []int16, buf []byte) (int, error) {
+			wide := make([]int32, len(s))
+			for i, v := range s {
+				wide[i] = int32(v) << 16
+			}
+			return l.EncodeInt32Interleaved(wide, buf)
+		}},
+	}
+	for _, test := range tests {
+		mp3, decoded := roundTrip(t, test.name, samples, test.encode)
+		if decoded == nil {
+			continue
+		}
+		if !bytes.Equal(mp3, expectedMp3) {
+			t.Errorf("%s, expected the same mp3 as int16 samples, got %d and %d bytes", test.name, len(mp3), len(expectedMp3))
+		}
+		if len(decoded) != len(expected) {
+			t.Errorf("%s, expected %d samples decoded, got %d", test.name, len(expected), len(decoded))
+			continue
+		}
+		// the amplitudes are kept on each channel, rather than garbage of full scale
+		var peaks [2]int16
+		for i, v := range decoded {
+			if v < 0 {
+				v = -v
+			}
+			if v > peaks[i%2] {
+				peaks[i%2] = v
+			}
+		}
+		if peaks[0] < 14000 || peaks[0] > 18000 || peaks[1] < 7000 || peaks[1] > 9000 {
+			t.Errorf("%s, unexpected peaks of the decoded channels, %v", test.name, peaks)
+		}
+	}
+}
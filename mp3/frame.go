@@ -0,0 +1,232 @@
+package mp3
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// MPEG audio frame headers
+// ref: http://www.mp3-tech.org/programmer/frame_header.html
+
+type (
+	// MPEG version
+	Version int
+
+	// MPEG layer
+	Layer int
+
+	// channel mode of a frame
+	ChannelMode int
+
+	// the 4-byte header every frame starts with
+	FrameHeader struct {
+		Version       Version
+		Layer         Layer
+		Protected     bool // true if a CRC follows the header
+		Bitrate       int  // kbps
+		SampleRate    int  // Hz
+		Padding       bool // true if the frame is padded with an extra slot
+		Private       bool
+		ChannelMode   ChannelMode
+		ModeExtension int // joint stereo only
+		Copyright     bool
+		Original      bool
+		Emphasis      int
+	}
+)
+
+// let us define versions here
+const (
+	MPEG_1   Version = iota // ISO/IEC 11172-3
+	MPEG_2                  // ISO/IEC 13818-3
+	MPEG_2_5                // unofficial extension for low sample rates
+)
+
+// let us define layers here
+const (
+	LAYER_1 Layer = iota + 1
+	LAYER_2
+	LAYER_3
+)
+
+// let us define channel modes here
+const (
+	CHANNEL_MODE_STEREO ChannelMode = iota
+	CHANNEL_MODE_JOINT_STEREO
+	CHANNEL_MODE_DUAL_CHANNEL
+	CHANNEL_MODE_MONO
+)
+
+const (
+	HEADER_SIZE = 4
+)
+
+var (
+	ErrNoSync              = errors.New("invalid frame header, no frame sync")
+	ErrInvalidVersion      = errors.New("invalid frame header, reserved MPEG version")
+	ErrInvalidLayer        = errors.New("invalid frame header, reserved layer")
+	ErrInvalidBitrate      = errors.New("invalid frame header, free-format or bad bitrate")
+	ErrInvalidSampleRate   = errors.New("invalid frame header, reserved sample rate")
+	ErrInvalidHeaderLength = errors.New("invalid frame header, expected 4 bytes")
+)
+
+// kbps, indexed by [MPEG_1 or not][layer - 1][bitrate index]
+var bitrates = [2][3][16]int{
+	{
+		{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448, -1},
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384, -1},
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, -1},
+	},
+	{
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256, -1},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, -1},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, -1},
+	},
+}
+
+// Hz, indexed by [version][sample rate index]
+var sampleRates = [3][3]int{
+	{44100, 48000, 32000},
+	{22050, 24000, 16000},
+	{11025, 12000, 8000},
+}
+
+func (v Version) String() string {
+	switch v {
+	case MPEG_1:
+		return "MPEG-1"
+	case MPEG_2:
+		return "MPEG-2"
+	case MPEG_2_5:
+		return "MPEG-2.5"
+	default:
+		return fmt.Sprintf("Version(%d)", int(v))
+	}
+}
+
+func (l Layer) String() string {
+	switch l {
+	case LAYER_1, LAYER_2, LAYER_3:
+		return fmt.Sprintf("Layer %d", int(l))
+	default:
+		return fmt.Sprintf("Layer(%d)", int(l))
+	}
+}
+
+func (m ChannelMode) String() string {
+	switch m {
+	case CHANNEL_MODE_STEREO:
+		return "stereo"
+	case CHANNEL_MODE_JOINT_STEREO:
+		return "joint stereo"
+	case CHANNEL_MODE_DUAL_CHANNEL:
+		return "dual channel"
+	case CHANNEL_MODE_MONO:
+		return "mono"
+	default:
+		return fmt.Sprintf("ChannelMode(%d)", int(m))
+	}
+}
+
+// true if b starts with the 11 bits of frame sync
+func isSync(b []byte) bool {
+	return len(b) >= 2 && b[0] == 0xff && b[1]&0xe0 == 0xe0
+}
+
+// parse the first 4 bytes of b as a frame header
+// free-format frames are not supported, as their size cannot be told from the header
+func ParseHeader(b []byte) (h FrameHeader, err error) {
+	if len(b) < HEADER_SIZE {
+		return h, ErrInvalidHeaderLength
+	}
+	if !isSync(b) {
+		return h, ErrNoSync
+	}
+	switch (b[1] >> 3) & 3 {
+	case 0:
+		h.Version = MPEG_2_5
+	case 2:
+		h.Version = MPEG_2
+	case 3:
+		h.Version = MPEG_1
+	default:
+		return h, ErrInvalidVersion
+	}
+	if h.Layer = Layer(4 - (b[1]>>1)&3); h.Layer > LAYER_3 {
+		return h, ErrInvalidLayer
+	}
+	h.Protected = b[1]&1 == 0
+
+	var v2 = 0
+	if h.Version != MPEG_1 {
+		v2 = 1
+	}
+	if h.Bitrate = bitrates[v2][h.Layer-1][b[2]>>4]; h.Bitrate <= 0 {
+		return h, ErrInvalidBitrate
+	}
+	sampleRateIndex := (b[2] >> 2) & 3
+	if sampleRateIndex == 3 {
+		return h, ErrInvalidSampleRate
+	}
+	h.SampleRate = sampleRates[h.Version][sampleRateIndex]
+	h.Padding = (b[2]>>1)&1 == 1
+	h.Private = b[2]&1 == 1
+
+	h.ChannelMode = ChannelMode(b[3] >> 6)
+	h.ModeExtension = int((b[3] >> 4) & 3)
+	h.Copyright = (b[3]>>3)&1 == 1
+	h.Original = (b[3]>>2)&1 == 1
+	h.Emphasis = int(b[3] & 3)
+	return h, nil
+}
+
+// count of samples (per channel) a frame holds
+func (h FrameHeader) SamplesPerFrame() int {
+	switch {
+	case h.Layer == LAYER_1:
+		return 384
+	case h.Layer == LAYER_3 && h.Version != MPEG_1:
+		return 576
+	default:
+		return 1152
+	}
+}
+
+// size of the whole frame in bytes, including the header
+func (h FrameHeader) Size() int {
+	var padding = 0
+	if h.Padding {
+		padding = 1
+	}
+	if h.Layer == LAYER_1 {
+		return (12*h.Bitrate*1000/h.SampleRate + padding) * 4
+	}
+	return h.SamplesPerFrame()/8*h.Bitrate*1000/h.SampleRate + padding
+}
+
+// playing time of a frame
+func (h FrameHeader) Duration() time.Duration {
+	return time.Duration(h.SamplesPerFrame()) * time.Second / time.Duration(h.SampleRate)
+}
+
+func (h FrameHeader) NumChannels() int {
+	if h.ChannelMode == CHANNEL_MODE_MONO {
+		return 1
+	}
+	return 2
+}
+
+// size of the layer 3 side information, which follows the header (and the CRC)
+func (h FrameHeader) sideInfoSize() int {
+	switch {
+	case h.Version == MPEG_1 && h.ChannelMode == CHANNEL_MODE_MONO:
+		return 17
+	case h.Version == MPEG_1:
+		return 32
+	case h.ChannelMode == CHANNEL_MODE_MONO:
+		return 9
+	default:
+		return 17
+	}
+}
@@ -0,0 +1,83 @@
+package mp3
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_ParseHeader(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   []byte
+		expected FrameHeader
+		size     int
+		samples  int
+	}{
+		{"mpeg1 layer3 128k", []byte{0xff, 0xfb, 0x90, 0x64}, FrameHeader{
+			Version: MPEG_1, Layer: LAYER_3, Bitrate: 128, SampleRate: 44100,
+			ChannelMode: CHANNEL_MODE_JOINT_STEREO, ModeExtension: 2, Original: true,
+		}, 417, 1152},
+		{"mpeg1 layer3 128k padded", []byte{0xff, 0xfb, 0x92, 0x64}, FrameHeader{
+			Version: MPEG_1, Layer: LAYER_3, Bitrate: 128, SampleRate: 44100, Padding: true,
+			ChannelMode: CHANNEL_MODE_JOINT_STEREO, ModeExtension: 2, Original: true,
+		}, 418, 1152},
+		{"mpeg2 layer3 crc mono", []byte{0xff, 0xf2, 0x88, 0xc0}, FrameHeader{
+			Version: MPEG_2, Layer: LAYER_3, Protected: true, Bitrate: 64, SampleRate: 16000,
+			ChannelMode: CHANNEL_MODE_MONO,
+		}, 288, 576},
+		{"mpeg2.5 layer3 8k", []byte{0xff, 0xe3, 0x18, 0xc4}, FrameHeader{
+			Version: MPEG_2_5, Layer: LAYER_3, Bitrate: 8, SampleRate: 8000,
+			ChannelMode: CHANNEL_MODE_MONO, Original: true,
+		}, 72, 576},
+		{"mpeg1 layer2 192k", []byte{0xff, 0xfd, 0xa4, 0x00}, FrameHeader{
+			Version: MPEG_1, Layer: LAYER_2, Bitrate: 192, SampleRate: 48000,
+		}, 576, 1152},
+		{"mpeg1 layer1 384k", []byte{0xff, 0xff, 0xc8, 0x00}, FrameHeader{
+			Version: MPEG_1, Layer: LAYER_1, Bitrate: 384, SampleRate: 32000,
+		}, 576, 384},
+	}
+	for _, test := range tests {
+		h, err := ParseHeader(test.header)
+		if err != nil {
+			t.Errorf("%s, cannot parse header, %s", test.name, err.Error())
+			continue
+		}
+		if h != test.expected {
+			t.Errorf("%s, expected %+v, got %+v", test.name, test.expected, h)
+		}
+		if h.Size() != test.size {
+			t.Errorf("%s, expected size %d, got %d", test.name, test.size, h.Size())
+		}
+		if h.SamplesPerFrame() != test.samples {
+			t.Errorf("%s, expected %d samples, got %d", test.name, test.samples, h.SamplesPerFrame())
+		}
+	}
+}
+
+func Test_ParseHeader_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   []byte
+		expected error
+	}{
+		{"short", []byte{0xff, 0xfb, 0x90}, ErrInvalidHeaderLength},
+		{"no sync", []byte{0x49, 0x44, 0x33, 0x04}, ErrNoSync},
+		{"reserved version", []byte{0xff, 0xeb, 0x90, 0x64}, ErrInvalidVersion},
+		{"reserved layer", []byte{0xff, 0xf9, 0x90, 0x64}, ErrInvalidLayer},
+		{"free format", []byte{0xff, 0xfb, 0x00, 0x64}, ErrInvalidBitrate},
+		{"bad bitrate", []byte{0xff, 0xfb, 0xf0, 0x64}, ErrInvalidBitrate},
+		{"reserved sample rate", []byte{0xff, 0xfb, 0x9c, 0x64}, ErrInvalidSampleRate},
+	}
+	for _, test := range tests {
+		if _, err := ParseHeader(test.header); err != test.expected {
+			t.Errorf("%s, expected %v, got %v", test.name, test.expected, err)
+		}
+	}
+}
+
+func Test_FrameHeader_Duration(t *testing.T) {
+	h, _ := ParseHeader([]byte{0xff, 0xfb, 0x94, 0x64}) // 48000Hz
+	if d := h.Duration(); d != 24*time.Millisecond {
+		t.Errorf("expected 24ms, got %s", d)
+	}
+}
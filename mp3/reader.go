@@ -0,0 +1,223 @@
+package mp3
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+// Iterating frames of an mp3 stream, skipping ID3v2, ID3v1 and APE tags, as well as junk between frames
+
+type (
+	// a single frame
+	Frame struct {
+		FrameHeader
+		Offset int64    // position of the frame in the stream
+		CRC    uint16   // only if Protected
+		Data   []byte   // the whole frame, header included
+		Xing   *XingTag // non-nil if this is the Xing/Info frame at the beginning, rather than audio
+	}
+
+	// reads frames one by one
+	// NOT thread-safe!
+	Reader struct {
+		input   *bufio.Reader
+		offset  int64 // position of the input
+		frames  int   // count of frames read so far
+		synced  bool  // true if the last frame was followed by another one directly
+		Skipped int64 // bytes of tags and junk skipped so far
+	}
+
+	// aggregate stats of a stream
+	Stats struct {
+		Frames         int           // count of audio frames, the Xing/Info one excluded
+		Samples        int64         // count of samples per channel
+		Bytes          int64         // size of audio frames
+		Duration       time.Duration // playing time
+		AverageBitrate float64       // kbps
+		VBR            bool          // true if the bitrate varies among frames
+		Header         FrameHeader   // header of the first audio frame
+		Xing           *XingTag      // nil if there is no Xing/Info frame
+		Skipped        int64         // bytes of tags and junk between frames
+	}
+)
+
+const (
+	_ID3V2_HEADER_SIZE = 10
+	_ID3V1_SIZE        = 128
+	_APE_HEADER_SIZE   = 32
+	_APE_FLAG_HEADER   = 1 << 29
+	_TAG_PEEK_SIZE     = _ID3V1_SIZE + _APE_HEADER_SIZE // enough to tell an ID3v1 tag from "TAG" in audio
+	_LOOKAHEAD_SIZE    = 64 << 10                       // APE tags without header larger than it are skipped as junk
+)
+
+func NewReader(input io.Reader) *Reader {
+	return &Reader{input: bufio.NewReaderSize(input, _LOOKAHEAD_SIZE)}
+}
+
+// the next frame, io.EOF if there are no more frames, or io.ErrUnexpectedEOF if the last frame is truncated
+func (r *Reader) Next() (*Frame, error) {
+	for {
+		b, err := r.input.Peek(_TAG_PEEK_SIZE)
+		if len(b) < HEADER_SIZE {
+			if len(b) > 0 {
+				r.skip(len(b))
+			}
+			if err == nil || err == io.EOF {
+				err = io.EOF
+			}
+			return nil, err
+		}
+		if size := tagSize(b); size > 0 {
+			if err = r.skip(size); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		h, err := ParseHeader(b)
+		if err != nil || !r.confirm(h) {
+			// an APE tag without header right after the last frame, found by its footer
+			if r.synced {
+				if size := r.apeFooterTagSize(); size > 0 {
+					if err = r.skip(size); err != nil {
+						return nil, err
+					}
+					continue
+				}
+			}
+			// junk, try the next byte
+			r.synced = false
+			r.skip(1)
+			continue
+		}
+		frame := &Frame{FrameHeader: h, Offset: r.offset, Data: make([]byte, h.Size())}
+		if _, err = io.ReadFull(r.input, frame.Data); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		r.offset += int64(len(frame.Data))
+		if h.Protected && len(frame.Data) >= HEADER_SIZE+2 {
+			frame.CRC = binary.BigEndian.Uint16(frame.Data[HEADER_SIZE:])
+		}
+		if r.frames == 0 {
+			frame.Xing = parseXingTag(h, frame.Data)
+		}
+		r.frames++
+		r.synced = true
+		return frame, nil
+	}
+}
+
+// size of the ID3v2, ID3v1 or APE tag b starts with, 0 if none
+// b should hold _TAG_PEEK_SIZE bytes unless the stream ends, as "TAG" is an ID3v1 tag only if it is the last 128 bytes,
+// or followed by an APE tag
+func tagSize(b []byte) int {
+	switch {
+	case len(b) >= _ID3V2_HEADER_SIZE && bytes.HasPrefix(b, []byte("ID3")):
+		// syncsafe integer, 7 bits per byte
+		size := int(b[6]&0x7f)<<21 | int(b[7]&0x7f)<<14 | int(b[8]&0x7f)<<7 | int(b[9]&0x7f)
+		if b[5]&0x10 != 0 { // footer present
+			size += _ID3V2_HEADER_SIZE
+		}
+		return _ID3V2_HEADER_SIZE + size
+	case bytes.HasPrefix(b, []byte("TAG")):
+		if len(b) == _ID3V1_SIZE || len(b) > _ID3V1_SIZE && bytes.HasPrefix(b[_ID3V1_SIZE:], []byte("APETAGEX")) {
+			return _ID3V1_SIZE
+		}
+		return 0
+	case len(b) >= _APE_HEADER_SIZE && bytes.HasPrefix(b, []byte("APETAGEX")):
+		// the size covers items and footer, excluding the header
+		if binary.LittleEndian.Uint32(b[20:])&_APE_FLAG_HEADER != 0 {
+			return _APE_HEADER_SIZE + int(binary.LittleEndian.Uint32(b[12:]))
+		}
+		return _APE_HEADER_SIZE // the footer of a tag larger than _LOOKAHEAD_SIZE, whose items are skipped as junk
+	default:
+		return 0
+	}
+}
+
+// size of the APE tag without header starting here, found by the footer within _LOOKAHEAD_SIZE bytes, 0 if none
+// the size in the footer covers items and footer, i.e., the whole tag
+func (r *Reader) apeFooterTagSize() int {
+	b, _ := r.input.Peek(_LOOKAHEAD_SIZE) // fewer at the end of the stream
+	for i := 0; ; i++ {
+		found := bytes.Index(b[i:], []byte("APETAGEX"))
+		if found < 0 {
+			return 0
+		}
+		i += found
+		if footer := b[i:]; len(footer) >= _APE_HEADER_SIZE &&
+			binary.LittleEndian.Uint32(footer[20:])&_APE_FLAG_HEADER == 0 &&
+			int(binary.LittleEndian.Uint32(footer[12:])) == i+_APE_HEADER_SIZE {
+			return i + _APE_HEADER_SIZE
+		}
+	}
+}
+
+// after junk, a frame is believed only if what follows it looks like another frame, a tag, or nothing
+func (r *Reader) confirm(h FrameHeader) bool {
+	if r.synced {
+		return true
+	}
+	size := h.Size()
+	b, _ := r.input.Peek(size + _TAG_PEEK_SIZE)
+	if len(b) <= size {
+		return true
+	}
+	next := b[size:]
+	if len(next) < HEADER_SIZE || tagSize(next) > 0 {
+		return true
+	}
+	n, err := ParseHeader(next)
+	return err == nil && n.Version == h.Version && n.Layer == h.Layer && n.SampleRate == h.SampleRate
+}
+
+func (r *Reader) skip(n int) error {
+	discarded, err := r.input.Discard(n)
+	r.offset += int64(discarded)
+	r.Skipped += int64(discarded)
+	if err == io.EOF {
+		return nil // a truncated tag at the end
+	}
+	return err
+}
+
+// take the frame into account
+func (s *Stats) Add(frame *Frame) {
+	if frame.Xing != nil {
+		s.Xing = frame.Xing
+		return
+	}
+	if s.Frames == 0 {
+		s.Header = frame.FrameHeader
+	} else if frame.Bitrate != s.Header.Bitrate {
+		s.VBR = true
+	}
+	s.Frames++
+	s.Samples += int64(frame.SamplesPerFrame())
+	s.Bytes += int64(len(frame.Data))
+	s.Duration = time.Duration(s.Samples) * time.Second / time.Duration(s.Header.SampleRate)
+	if seconds := s.Duration.Seconds(); seconds > 0 {
+		s.AverageBitrate = float64(s.Bytes) * 8 / seconds / 1000
+	}
+}
+
+// read all the frames, and gather the stats
+func Inspect(input io.Reader) (*Stats, error) {
+	var stats = new(Stats)
+	var reader = NewReader(input)
+	for {
+		frame, err := reader.Next()
+		stats.Skipped = reader.Skipped
+		if err == io.EOF {
+			return stats, nil
+		} else if err != nil {
+			return stats, err
+		}
+		stats.Add(frame)
+	}
+}
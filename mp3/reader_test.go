@@ -0,0 +1,223 @@
+package mp3
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+	"time"
+)
+
+// a frame of the given header, filled with fill
+func buildFrame(header []byte, fill byte) []byte {
+	h, _ := ParseHeader(header)
+	frame := bytes.Repeat([]byte{fill}, h.Size())
+	copy(frame, header)
+	return frame
+}
+
+// an Info frame with all the optional fields and the LAME extension, as LAME writes for CBR
+func buildInfoFrame(header []byte, frames, size int, delay, padding int) []byte {
+	h, _ := ParseHeader(header)
+	frame := buildFrame(header, 0)
+	tag := new(bytes.Buffer)
+	tag.WriteString("Info")
+	binary.Write(tag, binary.BigEndian, uint32(_XING_FLAG_FRAMES|_XING_FLAG_BYTES|_XING_FLAG_TOC|_XING_FLAG_QUALITY))
+	binary.Write(tag, binary.BigEndian, uint32(frames))
+	binary.Write(tag, binary.BigEndian, uint32(size))
+	tag.Write(make([]byte, 100))
+	binary.Write(tag, binary.BigEndian, uint32(57))
+	lame := make([]byte, _LAME_TAG_SIZE)
+	copy(lame, "LAME3.100")
+	lame[_LAME_TAG_DELAY_OFFSET] = byte(delay >> 4)
+	lame[_LAME_TAG_DELAY_OFFSET+1] = byte(delay<<4) | byte(padding>>8)
+	lame[_LAME_TAG_DELAY_OFFSET+2] = byte(padding)
+	tag.Write(lame)
+	copy(frame[HEADER_SIZE+h.sideInfoSize():], tag.Bytes())
+	return frame
+}
+
+func buildId3v2(size int) []byte {
+	tag := []byte{'I', 'D', '3', 4, 0, 0, byte(size >> 21 & 0x7f), byte(size >> 14 & 0x7f), byte(size >> 7 & 0x7f), byte(size & 0x7f)}
+	return append(tag, make([]byte, size)...)
+}
+
+func buildApe(items []byte, withHeader bool) []byte {
+	header := func(flags uint32) []byte {
+		b := new(bytes.Buffer)
+		b.WriteString("APETAGEX")
+		binary.Write(b, binary.LittleEndian, uint32(2000))
+		binary.Write(b, binary.LittleEndian, uint32(len(items)+_APE_HEADER_SIZE))
+		binary.Write(b, binary.LittleEndian, uint32(1))
+		binary.Write(b, binary.LittleEndian, flags)
+		b.Write(make([]byte, 8))
+		return b.Bytes()
+	}
+	if !withHeader {
+		return append(append([]byte{}, items...), header(0)...)
+	}
+	ape := header(_APE_FLAG_HEADER | 1<<31)
+	ape = append(ape, items...)
+	return append(ape, header(1<<31)...)
+}
+
+// an APE item of the given key and value
+func buildApeItem(key, value string) []byte {
+	b := new(bytes.Buffer)
+	binary.Write(b, binary.LittleEndian, uint32(len(value)))
+	binary.Write(b, binary.LittleEndian, uint32(0))
+	b.WriteString(key)
+	b.WriteByte(0)
+	b.WriteString(value)
+	return b.Bytes()
+}
+
+func Test_Inspect(t *testing.T) {
+	h128 := []byte{0xff, 0xfb, 0x90, 0x64}
+	h160 := []byte{0xff, 0xfb, 0xa0, 0x64}
+
+	stream := new(bytes.Buffer)
+	stream.Write(buildId3v2(300))
+	stream.Write(buildInfoFrame(h128, 3, 417*2+522, 576, 1234))
+	stream.Write(buildFrame(h128, 0x55))
+	stream.Write([]byte{0xff, 0xfb, 0x00}) // junk looking like a sync
+	stream.Write(buildFrame(h160, 0x55))
+	stream.Write(buildFrame(h128, 0x55))
+	// items look like frames, but are skipped along with the APE tag
+	stream.Write(buildApe(buildFrame(h128, 0x55), true))
+	id3v1 := make([]byte, _ID3V1_SIZE)
+	copy(id3v1, "TAG")
+	stream.Write(id3v1)
+
+	stats, err := Inspect(stream)
+	if err != nil {
+		t.Errorf("cannot inspect, %s", err.Error())
+		return
+	}
+	if stats.Frames != 3 {
+		t.Errorf("expected 3 frames, got %d", stats.Frames)
+	}
+	if stats.Samples != 3*1152 {
+		t.Errorf("expected %d samples, got %d", 3*1152, stats.Samples)
+	}
+	if stats.Bytes != 417*2+522 {
+		t.Errorf("expected %d bytes, got %d", 417*2+522, stats.Bytes)
+	}
+	if expected := time.Duration(3*1152) * time.Second / 44100; stats.Duration != expected {
+		t.Errorf("expected duration %s, got %s", expected, stats.Duration)
+	}
+	if !stats.VBR {
+		t.Errorf("expected VBR")
+	}
+	if stats.AverageBitrate < 128 || stats.AverageBitrate > 160 {
+		t.Errorf("expected average bitrate between 128 and 160, got %f", stats.AverageBitrate)
+	}
+	if stats.Header.Bitrate != 128 {
+		t.Errorf("expected the first header of 128kbps, got %d", stats.Header.Bitrate)
+	}
+	if stats.Skipped != int64(310+3+len(buildApe(buildFrame(h128, 0), true))+_ID3V1_SIZE) {
+		t.Errorf("unexpected skipped bytes, %d", stats.Skipped)
+	}
+	if stats.Xing == nil {
+		t.Errorf("expected Info tag")
+		return
+	}
+	if stats.Xing.Id != "Info" || stats.Xing.Frames != 3 || stats.Xing.Bytes != 417*2+522 || len(stats.Xing.Toc) != 100 || stats.Xing.Quality != 57 {
+		t.Errorf("unexpected Info tag, %+v", stats.Xing)
+	}
+	if lame := stats.Xing.Lame; lame == nil || lame.Encoder != "LAME3.100" || lame.EncoderDelay != 576 || lame.EncoderPadding != 1234 {
+		t.Errorf("unexpected LAME tag, %+v", lame)
+	}
+}
+
+func Test_Reader_Frames(t *testing.T) {
+	h := []byte{0xff, 0xf2, 0x88, 0xc0} // with CRC
+	stream := new(bytes.Buffer)
+	for i := 0; i < 5; i++ {
+		frame := buildFrame(h, byte(i))
+		frame[4], frame[5] = 0xbe, 0xef
+		stream.Write(frame)
+	}
+	reader := NewReader(stream)
+	for i := 0; i < 5; i++ {
+		frame, err := reader.Next()
+		if err != nil {
+			t.Errorf("cannot read frame %d, %s", i, err.Error())
+			return
+		}
+		if frame.Offset != int64(i*288) || len(frame.Data) != 288 || frame.Data[287] != byte(i) {
+			t.Errorf("unexpected frame %d, offset=%d size=%d", i, frame.Offset, len(frame.Data))
+		}
+		if frame.CRC != 0xbeef {
+			t.Errorf("expected CRC 0xbeef, got %#x", frame.CRC)
+		}
+		if frame.Xing != nil {
+			t.Errorf("expected no Xing tag")
+		}
+	}
+	if _, err := reader.Next(); err != io.EOF {
+		t.Errorf("expected EOF, got %v", err)
+	}
+}
+
+// an APE tag without header is skipped as a whole, by the size in its footer
+func Test_Reader_ApeWithoutHeader(t *testing.T) {
+	h := []byte{0xff, 0xfb, 0x90, 0x64}
+	ape := buildApe(append(buildApeItem("Title", "TAG in a title"), buildApeItem("Artist", "\xff\xfb")...), false)
+	stream := new(bytes.Buffer)
+	stream.Write(buildFrame(h, 0x55))
+	stream.Write(buildFrame(h, 0x55))
+	stream.Write(ape)
+	id3v1 := make([]byte, _ID3V1_SIZE)
+	copy(id3v1, "TAG")
+	stream.Write(id3v1)
+
+	stats, err := Inspect(stream)
+	if err != nil {
+		t.Errorf("cannot inspect, %s", err.Error())
+		return
+	}
+	if stats.Frames != 2 || stats.Skipped != int64(len(ape)+_ID3V1_SIZE) {
+		t.Errorf("expected 2 frames and %d bytes skipped, got %d and %d", len(ape)+_ID3V1_SIZE, stats.Frames, stats.Skipped)
+	}
+}
+
+// "TAG" is an ID3v1 tag only at the last 128 bytes, or followed by an APE tag
+func Test_Reader_Id3v1(t *testing.T) {
+	h := []byte{0xff, 0xfb, 0x90, 0x64}
+	id3v1 := make([]byte, _ID3V1_SIZE)
+	copy(id3v1, "TAG")
+	ape := buildApe(buildApeItem("Title", "title"), true)
+	tests := []struct {
+		name    string
+		stream  [][]byte
+		frames  int
+		skipped int
+	}{
+		{"junk", [][]byte{buildFrame(h, 0x55), buildFrame(h, 0x55), []byte("TAG.."), buildFrame(h, 0x55), buildFrame(h, 0x55)}, 4, 5},
+		{"at the end", [][]byte{buildFrame(h, 0x55), id3v1}, 1, _ID3V1_SIZE},
+		{"before APE", [][]byte{buildFrame(h, 0x55), id3v1, ape}, 1, _ID3V1_SIZE + len(ape)},
+	}
+	for _, test := range tests {
+		stats, err := Inspect(bytes.NewReader(bytes.Join(test.stream, nil)))
+		if err != nil {
+			t.Errorf("%s: cannot inspect, %s", test.name, err.Error())
+			continue
+		}
+		if stats.Frames != test.frames || stats.Skipped != int64(test.skipped) {
+			t.Errorf("%s: expected %d frames and %d bytes skipped, got %d and %d", test.name, test.frames, test.skipped, stats.Frames, stats.Skipped)
+		}
+	}
+}
+
+func Test_Reader_Truncated(t *testing.T) {
+	h := []byte{0xff, 0xfb, 0x90, 0x64}
+	stream := append(buildFrame(h, 0x55), buildFrame(h, 0x55)[:100]...)
+	reader := NewReader(bytes.NewReader(stream))
+	if _, err := reader.Next(); err != nil {
+		t.Errorf("cannot read the first frame, %s", err.Error())
+	}
+	if _, err := reader.Next(); err != io.ErrUnexpectedEOF {
+		t.Errorf("expected ErrUnexpectedEOF, got %v", err)
+	}
+}
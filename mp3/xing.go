@@ -0,0 +1,95 @@
+package mp3
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// The Xing/Info tag, which LAME writes into the first frame instead of audio, and the LAME extension following it
+// ref: http://gabriel.mp3-tech.org/mp3infotag.html
+
+type (
+	// the Xing (VBR) or Info (CBR) tag
+	XingTag struct {
+		Id      string // "Xing" or "Info"
+		Frames  int    // count of audio frames, -1 if absent
+		Bytes   int    // size of the stream, -1 if absent
+		Toc     []byte // 100 seek points, nil if absent
+		Quality int    // VBR scale, -1 if absent
+
+		Lame *LameTag // nil if not encoded by LAME
+	}
+
+	// the LAME extension of the Xing/Info tag
+	LameTag struct {
+		Encoder        string // e.g., "LAME3.100"
+		EncoderDelay   int    // samples added at the beginning
+		EncoderPadding int    // samples added at the end
+	}
+)
+
+const (
+	_XING_FLAG_FRAMES  = 1
+	_XING_FLAG_BYTES   = 2
+	_XING_FLAG_TOC     = 4
+	_XING_FLAG_QUALITY = 8
+
+	_LAME_TAG_SIZE         = 36
+	_LAME_TAG_DELAY_OFFSET = 21
+)
+
+// the Xing/Info tag inside the given frame (header included), nil if there is none
+func parseXingTag(h FrameHeader, frame []byte) *XingTag {
+	if h.Layer != LAYER_3 {
+		return nil
+	}
+	pos := HEADER_SIZE + h.sideInfoSize()
+	if h.Protected {
+		pos += 2
+	}
+	if len(frame) < pos+8 {
+		return nil
+	}
+	id := string(frame[pos : pos+4])
+	if id != "Xing" && id != "Info" {
+		return nil
+	}
+	tag := &XingTag{Id: id, Frames: -1, Bytes: -1, Quality: -1}
+	flags := binary.BigEndian.Uint32(frame[pos+4:])
+	pos += 8
+	// read the next optional field, nil if absent or the frame is too short
+	field := func(flag uint32, size int) []byte {
+		if flags&flag == 0 || len(frame) < pos+size {
+			return nil
+		}
+		pos += size
+		return frame[pos-size : pos]
+	}
+	if b := field(_XING_FLAG_FRAMES, 4); b != nil {
+		tag.Frames = int(binary.BigEndian.Uint32(b))
+	}
+	if b := field(_XING_FLAG_BYTES, 4); b != nil {
+		tag.Bytes = int(binary.BigEndian.Uint32(b))
+	}
+	if b := field(_XING_FLAG_TOC, 100); b != nil {
+		tag.Toc = append([]byte(nil), b...)
+	}
+	if b := field(_XING_FLAG_QUALITY, 4); b != nil {
+		tag.Quality = int(binary.BigEndian.Uint32(b))
+	}
+	tag.Lame = parseLameTag(frame[pos:])
+	return tag
+}
+
+// the LAME extension, nil if b does not start with it
+func parseLameTag(b []byte) *LameTag {
+	if len(b) < _LAME_TAG_SIZE || !bytes.HasPrefix(b, []byte("LAME")) && !bytes.HasPrefix(b, []byte("Lavc")) {
+		return nil
+	}
+	d := b[_LAME_TAG_DELAY_OFFSET:]
+	return &LameTag{
+		Encoder:        string(bytes.TrimRight(b[:9], "\x00 ")),
+		EncoderDelay:   int(d[0])<<4 | int(d[1])>>4,
+		EncoderPadding: int(d[1]&0x0f)<<8 | int(d[2]),
+	}
+}
@@ -0,0 +1,85 @@
+package lame
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/sunicy/go-lame/mp3"
+)
+
+// the frames parsed by mp3 should be exactly what lame reports
+func Test_Mp3_Inspect(t *testing.T) {
+	data, err := ioutil.ReadFile("res/1chan_s16ple.raw")
+	if err != nil {
+		t.Errorf("cannot read file, %s", err.Error())
+		return
+	}
+	pcm := make([]int16, len(data)/2)
+	for i := range pcm {
+		pcm[i] = int16(data[i*2]) | int16(data[i*2+1])<<8
+	}
+	tests := []struct {
+		name string
+		vbr  VBRMode
+	}{
+		{"cbr", VBR_OFF},
+		{"vbr", VBR_DEFAULT},
+	}
+	for _, test := range tests {
+		l, err := NewLame()
+		if err != nil {
+			t.Errorf("cannot create lame, %s", err.Error())
+			return
+		}
+		l.SetInSampleRate(16000)
+		l.SetOutSampleRate(16000)
+		l.SetNumChannels(1)
+		l.SetMode(MODE_MONO)
+		l.SetVBR(test.vbr)
+		l.Id3tagInit()
+		l.Id3tagAddV2()
+		l.Id3tagSetTitle("inspect")
+		if err = l.InitParams(); err != nil {
+			t.Errorf("%s, cannot init params, %s", test.name, err.Error())
+			l.Close()
+			continue
+		}
+		out := new(bytes.Buffer)
+		mp3Buf := make([]byte, len(pcm)*5/4+7200)
+		if n, err := l.EncodeInt16(pcm, pcm, mp3Buf); err != nil {
+			t.Errorf("%s, cannot encode, %s", test.name, err.Error())
+		} else {
+			out.Write(mp3Buf[:n])
+		}
+		if residual, err := l.EncodeFlush(); err != nil {
+			t.Errorf("%s, cannot flush, %s", test.name, err.Error())
+		} else {
+			out.Write(residual)
+		}
+		frames, _ := l.GetFrameNum()
+		frameSize, _ := l.GetFramesize()
+		l.Close()
+
+		stats, err := mp3.Inspect(out)
+		if err != nil {
+			t.Errorf("%s, cannot inspect, %s", test.name, err.Error())
+			continue
+		}
+		if stats.Frames != frames {
+			t.Errorf("%s, expected %d frames, got %d", test.name, frames, stats.Frames)
+		}
+		if stats.Header.SamplesPerFrame() != frameSize {
+			t.Errorf("%s, expected %d samples per frame, got %d", test.name, frameSize, stats.Header.SamplesPerFrame())
+		}
+		if stats.Header.SampleRate != 16000 || stats.Header.ChannelMode != mp3.CHANNEL_MODE_MONO {
+			t.Errorf("%s, unexpected header, %+v", test.name, stats.Header)
+		}
+		if test.vbr == VBR_OFF && stats.VBR {
+			t.Errorf("%s, expected CBR", test.name)
+		}
+		if stats.Skipped == 0 {
+			t.Errorf("%s, expected ID3 tags skipped", test.name)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package lame
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// Optional params of EncodeOptions, which are applied only if set explicitly, leaving LAME's defaults otherwise
+// They are values rather than pointers, so that EncodeOptions remains comparable, e.g., as a key of Pool, e.g.,
+// opts.LowpassFreq = lame.Int(16000)
+// opts.FindReplayGain = lame.Bool(true)
+// In JSON, they are plain values, or null (or missing) if not set
+
+type (
+	// an int which is applied only if Set
+	OptionalInt struct {
+		Value int
+		Set   bool
+	}
+
+	// a float32 which is applied only if Set
+	OptionalFloat32 struct {
+		Value float32
+		Set   bool
+	}
+
+	// a bool which is applied only if Set
+	OptionalBool struct {
+		Value bool
+		Set   bool
+	}
+)
+
+var (
+	ErrInvalidFilterFreq = errors.New("invalid filter frequency, expected -1 (disabled), 0 (lame chooses) or Hz")
+	ErrFilterConflict    = errors.New("highpass frequency is expected below lowpass frequency")
+	ErrInvalidScale      = errors.New("invalid scale, expected a positive one")
+	ErrInvalidEmphasis   = errors.New("invalid emphasis, expected 0 (none), 1 (50/15 ms) or 3 (CCITT J.17)")
+)
+
+// an int set explicitly
+func Int(v int) OptionalInt {
+	return OptionalInt{Value: v, Set: true}
+}
+
+// a float32 set explicitly
+func Float32(v float32) OptionalFloat32 {
+	return OptionalFloat32{Value: v, Set: true}
+}
+
+// a bool set explicitly
+func Bool(v bool) OptionalBool {
+	return OptionalBool{Value: v, Set: true}
+}
+
+// 1 if true, 0 otherwise, as the setters of lame expect
+func (b OptionalBool) int() int {
+	if b.Value {
+		return 1
+	}
+	return 0
+}
+
+// JSON null if not set
+func (o OptionalInt) MarshalJSON() ([]byte, error) {
+	return marshalOptional(o.Set, o.Value)
+}
+
+func (o *OptionalInt) UnmarshalJSON(data []byte) error {
+	return unmarshalOptional(data, &o.Set, &o.Value)
+}
+
+// not set, so that omitzero omits it
+func (o OptionalInt) IsZero() bool {
+	return !o.Set
+}
+
+func (o OptionalFloat32) MarshalJSON() ([]byte, error) {
+	return marshalOptional(o.Set, o.Value)
+}
+
+func (o *OptionalFloat32) UnmarshalJSON(data []byte) error {
+	return unmarshalOptional(data, &o.Set, &o.Value)
+}
+
+func (o OptionalFloat32) IsZero() bool {
+	return !o.Set
+}
+
+func (o OptionalBool) MarshalJSON() ([]byte, error) {
+	return marshalOptional(o.Set, o.Value)
+}
+
+func (o *OptionalBool) UnmarshalJSON(data []byte) error {
+	return unmarshalOptional(data, &o.Set, &o.Value)
+}
+
+func (o OptionalBool) IsZero() bool {
+	return !o.Set
+}
+
+func marshalOptional(set bool, value interface{}) ([]byte, error) {
+	if !set {
+		return []byte("null"), nil
+	}
+	return json.Marshal(value)
+}
+
+// value is left untouched if data is null
+func unmarshalOptional(data []byte, set *bool, value interface{}) error {
+	if string(data) == "null" {
+		*set = false
+		return nil
+	}
+	if err := json.Unmarshal(data, value); err != nil {
+		return err
+	}
+	*set = true
+	return nil
+}
+
+// check the optional params which are set, into check(field, err)
+func (opts *EncodeOptions) validateOptional(check func(string, error)) {
+	for _, filter := range []struct {
+		field string
+		freq  OptionalInt
+	}{
+		{"LowpassFreq", opts.LowpassFreq},
+		{"HighpassFreq", opts.HighpassFreq},
+	} {
+		if filter.freq.Set && filter.freq.Value < -1 {
+			check(filter.field, ErrInvalidFilterFreq)
+		}
+	}
+	if opts.LowpassFreq.Set && opts.HighpassFreq.Set && opts.LowpassFreq.Value > 0 && opts.HighpassFreq.Value >= opts.LowpassFreq.Value {
+		check("HighpassFreq", ErrFilterConflict)
+	}
+	if opts.Scale.Set && !(opts.Scale.Value > 0) {
+		check("Scale", ErrInvalidScale)
+	}
+	if opts.Emphasis.Set && opts.Emphasis.Value != 0 && opts.Emphasis.Value != 1 && opts.Emphasis.Value != 3 {
+		check("Emphasis", ErrInvalidEmphasis)
+	}
+}
+
+// apply the optional params which are set onto the given lame
+func (opts *EncodeOptions) applyOptional(l *Lame) error {
+	setters := []struct {
+		set   bool
+		apply func() error
+	}{
+		{opts.LowpassFreq.Set, func() error { return l.SetLowpassfreq(opts.LowpassFreq.Value) }},
+		{opts.HighpassFreq.Set, func() error { return l.SetHighpassfreq(opts.HighpassFreq.Value) }},
+		{opts.Scale.Set, func() error { return l.SetScale(opts.Scale.Value) }},
+		{opts.FindReplayGain.Set, func() error { return l.SetFindReplayGain(opts.FindReplayGain.int()) }},
+		{opts.Copyright.Set, func() error { return l.SetCopyright(opts.Copyright.int()) }},
+		{opts.Original.Set, func() error { return l.SetOriginal(opts.Original.int()) }},
+		{opts.ErrorProtection.Set, func() error { return l.SetErrorProtection(opts.ErrorProtection.int()) }},
+		{opts.Emphasis.Set, func() error { return l.SetEmphasis(opts.Emphasis.Value) }},
+		{opts.StrictISO.Set, func() error { return l.SetStrictISO(opts.StrictISO.int()) }},
+	}
+	for _, setter := range setters {
+		if !setter.set {
+			continue
+		}
+		if err := setter.apply(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package lame
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func Test_EncodeOptions_Optional(t *testing.T) {
+	wr, err := NewWriter(new(bytes.Buffer))
+	if err != nil {
+		t.Errorf("cannot create lame writer, %s", err.Error())
+		return
+	}
+	defer wr.Close()
+	wr.EncodeOptions = monoOptions()
+	wr.LowpassFreq = Int(7000)
+	wr.Scale = Float32(0.5)
+	wr.Copyright = Bool(true)
+	wr.Original = Bool(false)
+	wr.Emphasis = Int(1)
+	// not set, so that it is left as it is
+	wr.lame.SetHighpassfreq(100)
+	if err = wr.ForceUpdateParams(); err != nil {
+		t.Errorf("cannot update params, %s", err.Error())
+		return
+	}
+	getters := []struct {
+		name     string
+		get      func() (int, error)
+		expected int
+	}{
+		{"lowpass", wr.lame.GetLowpassfreq, 7000},
+		{"highpass", wr.lame.GetHighpassfreq, 100},
+		{"copyright", wr.lame.GetCopyright, 1},
+		{"original", wr.lame.GetOriginal, 0},
+		{"emphasis", wr.lame.GetEmphasis, 1},
+	}
+	for _, getter := range getters {
+		if actual, err := getter.get(); err != nil || actual != getter.expected {
+			t.Errorf("%s, expected %d, got %d (%v)", getter.name, getter.expected, actual, err)
+		}
+	}
+	if scale, _ := wr.lame.GetScale(); scale != 0.5 {
+		t.Errorf("expected scale 0.5, got %f", scale)
+	}
+}
+
+func Test_EncodeOptions_ValidateOptional(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     func(opts *EncodeOptions)
+		expected error
+	}{
+		{"disabled filters", func(opts *EncodeOptions) { opts.LowpassFreq, opts.HighpassFreq = Int(-1), Int(-1) }, nil},
+		{"band", func(opts *EncodeOptions) { opts.LowpassFreq, opts.HighpassFreq = Int(7000), Int(80) }, nil},
+		{"lowpass", func(opts *EncodeOptions) { opts.LowpassFreq = Int(-2) }, ErrInvalidFilterFreq},
+		{"inverted band", func(opts *EncodeOptions) { opts.LowpassFreq, opts.HighpassFreq = Int(80), Int(7000) }, ErrFilterConflict},
+		{"scale", func(opts *EncodeOptions) { opts.Scale = Float32(0) }, ErrInvalidScale},
+		{"unset scale", func(opts *EncodeOptions) { opts.Scale = OptionalFloat32{Value: -1} }, nil},
+		{"emphasis", func(opts *EncodeOptions) { opts.Emphasis = Int(2) }, ErrInvalidEmphasis},
+	}
+	for _, test := range tests {
+		opts := monoOptions()
+		test.opts(&opts)
+		err := opts.Validate()
+		if test.expected == nil && err != nil || !errors.Is(err, test.expected) {
+			t.Errorf("%s, expected %v, got %v", test.name, test.expected, err)
+		}
+	}
+}
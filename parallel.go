@@ -0,0 +1,225 @@
+package lame
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"runtime"
+
+	"github.com/sunicy/go-lame/mp3"
+)
+
+// Encoding a large PCM input on multiple cores
+// The input is split into frame-aligned segments, each of which is encoded by its own Lame with the bit reservoir disabled,
+// so that every frame is self-contained. Each segment is fed with a few frames before and after it, warming up the
+// psychoacoustic model and covering the encoder delay, and only the frames it owns are kept.
+// As the encoder delay is the same for every segment, frames are stitched into one gapless stream, of exactly the frames
+// a serial encode would produce.
+// NOTE: no Xing/LAME tag is written, and the quality is slightly lower than a serial encode, as the bit reservoir is off
+
+type (
+	// a part of the input, in samples of each channel
+	parallelSegment struct {
+		start, end     int64 // samples owned
+		inStart, inEnd int64 // samples fed into the encoder, including the ones before and after
+		first, last    bool
+	}
+)
+
+const (
+	_PARALLEL_PREROLL_FRAMES      = 3   // frames fed before a segment
+	_PARALLEL_POSTROLL_FRAMES     = 3   // frames fed after a segment
+	_PARALLEL_MIN_SEGMENT_FRAMES  = 256 // frames of the shortest segment
+	_PARALLEL_SEGMENTS_PER_WORKER = 4   // so that workers finishing early get more work
+)
+
+var ErrUnknownSize = errors.New("unknown size of the input, expected Size() or Stat() on io.ReaderAt")
+
+/*
+encode the whole PCM of src into dst, with at most workers segments encoded at the same time, or runtime.NumCPU() if workers < 1
+src must have Size() (e.g., *bytes.Reader, *io.SectionReader) or Stat() (e.g., *os.File).
+for a WAV file, pass io.NewSectionReader(file, dataOffset, dataSize).
+it falls back to a serial encode if the sample rate has to be converted, or the input is too short to split
+*/
+func ParallelEncode(src io.ReaderAt, dst io.Writer, opts EncodeOptions, workers int) error {
+	size, err := readerAtSize(src)
+	if err != nil {
+		return err
+	}
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	if opts.InNumChannels < 1 {
+		return ErrUnsupportedChannelNum
+	}
+	sampleSize, err := bytesPerSample(opts.InSampleFormat, opts.InBitsPerSample)
+	if err != nil {
+		return err
+	}
+	var frameBytes = int64(sampleSize * opts.InNumChannels)
+	segments := planSegments(size/frameBytes, samplesPerFrame(opts.OutSampleRate), workers)
+	if len(segments) <= 1 || opts.InSampleRate != opts.OutSampleRate || NearestSampleRate(opts.InSampleRate) != opts.InSampleRate {
+		return EncodeContext(context.Background(), dst, io.NewSectionReader(src, 0, size), opts)
+	}
+
+	type result struct {
+		data []byte
+		err  error
+	}
+	var results = make([]chan result, len(segments))
+	for i := range results {
+		results[i] = make(chan result, 1)
+	}
+	var slots = make(chan struct{}, workers) // a slot is taken until the segment is written
+	var quit = make(chan struct{})
+	defer close(quit)
+	go func() {
+		for i, seg := range segments {
+			select {
+			case slots <- struct{}{}:
+			case <-quit:
+				return
+			}
+			go func(i int, seg parallelSegment) {
+				data, err := encodeSegment(src, frameBytes, opts, seg)
+				results[i] <- result{data, err}
+			}(i, seg)
+		}
+	}()
+	for i := range segments {
+		r := <-results[i]
+		if r.err != nil {
+			return r.err
+		}
+		if _, err = dst.Write(r.data); err != nil {
+			return err
+		}
+		<-slots
+	}
+	return nil
+}
+
+// the size of src, if it tells
+func readerAtSize(src io.ReaderAt) (int64, error) {
+	switch s := src.(type) {
+	case interface{ Size() int64 }:
+		return s.Size(), nil
+	case interface{ Stat() (os.FileInfo, error) }:
+		info, err := s.Stat()
+		if err != nil {
+			return 0, err
+		}
+		return info.Size(), nil
+	default:
+		return 0, ErrUnknownSize
+	}
+}
+
+// count of samples (of each channel) in a frame of the given sample rate
+func samplesPerFrame(sampleRate int) int64 {
+	if sampleRate >= 32000 {
+		return 1152 // MPEG-1
+	}
+	return 576 // MPEG-2 and MPEG-2.5
+}
+
+// split samples into frame-aligned segments
+func planSegments(samples int64, frameSize int64, workers int) []parallelSegment {
+	var frames = (samples + frameSize - 1) / frameSize
+	var segmentFrames = (frames + int64(workers*_PARALLEL_SEGMENTS_PER_WORKER) - 1) / int64(workers*_PARALLEL_SEGMENTS_PER_WORKER)
+	if segmentFrames < _PARALLEL_MIN_SEGMENT_FRAMES {
+		segmentFrames = _PARALLEL_MIN_SEGMENT_FRAMES
+	}
+	var segments []parallelSegment
+	for start := int64(0); start < samples || start == 0; start += segmentFrames * frameSize {
+		seg := parallelSegment{
+			start:   start,
+			end:     start + segmentFrames*frameSize,
+			inStart: start - _PARALLEL_PREROLL_FRAMES*frameSize,
+			first:   start == 0,
+		}
+		seg.inEnd = seg.end + _PARALLEL_POSTROLL_FRAMES*frameSize
+		if seg.inStart < 0 {
+			seg.inStart = 0
+		}
+		if seg.inEnd > samples {
+			seg.inEnd = samples
+		}
+		if seg.end >= samples {
+			seg.end, seg.inEnd, seg.last = samples, samples, true
+		}
+		segments = append(segments, seg)
+		if seg.last {
+			break
+		}
+	}
+	return segments
+}
+
+// encode the segment, and keep only the frames it owns
+func encodeSegment(src io.ReaderAt, frameBytes int64, opts EncodeOptions, seg parallelSegment) ([]byte, error) {
+	var pcm = make([]byte, (seg.inEnd-seg.inStart)*frameBytes)
+	n, err := src.ReadAt(pcm, seg.inStart*frameBytes)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	// ID3v2 is written at the beginning of the first segment, and ID3v1 at the end of the last one
+	if !seg.first && !seg.last {
+		opts.Tags = nil
+	}
+	var out = new(bytes.Buffer)
+	w, err := NewWriter(out)
+	if err != nil {
+		return nil, err
+	}
+	defer w.lame.Close()
+	w.EncodeOptions = opts
+	if err = w.lame.SetDisableReservoir(1); err != nil {
+		return nil, err
+	}
+	if err = w.lame.SetBWriteVbrTag(0); err != nil {
+		return nil, err
+	}
+	if _, err = w.Write(pcm[:n]); err != nil {
+		return nil, err
+	}
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+	return seg.cut(out.Bytes(), samplesPerFrame(opts.OutSampleRate))
+}
+
+// the frames owned by the segment, as well as the tags before the first one and after the last one
+func (seg parallelSegment) cut(data []byte, frameSize int64) ([]byte, error) {
+	var starts, ends []int64 // where every frame starts and ends
+	reader := mp3.NewReader(bytes.NewReader(data))
+	for {
+		frame, err := reader.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		starts = append(starts, frame.Offset)
+		ends = append(ends, frame.Offset+int64(len(frame.Data)))
+	}
+	var frames = int64(len(starts))
+	var first = (seg.start - seg.inStart) / frameSize
+	var last = first + (seg.end-seg.start)/frameSize
+	if seg.last || last > frames {
+		last = frames // flushed frames belong to the last segment
+	}
+	if first >= last {
+		return nil, nil
+	}
+	var start, end = starts[first], ends[last-1]
+	if seg.first {
+		start = 0
+	}
+	if seg.last {
+		end = int64(len(data))
+	}
+	return data[start:end], nil
+}
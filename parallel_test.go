@@ -0,0 +1,109 @@
+package lame
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/sunicy/go-lame/mp3"
+)
+
+func Test_PlanSegments(t *testing.T) {
+	const frameSize = 1152
+	tests := []struct {
+		samples  int64
+		workers  int
+		segments int
+	}{
+		{0, 4, 1},
+		{100, 4, 1},
+		{frameSize * _PARALLEL_MIN_SEGMENT_FRAMES, 4, 1},
+		{frameSize*_PARALLEL_MIN_SEGMENT_FRAMES + 1, 4, 2},
+		{frameSize * _PARALLEL_MIN_SEGMENT_FRAMES * 100, 4, 16},
+		{frameSize*_PARALLEL_MIN_SEGMENT_FRAMES*100 + 7, 2, 8},
+	}
+	for _, test := range tests {
+		segments := planSegments(test.samples, frameSize, test.workers)
+		if len(segments) != test.segments {
+			t.Errorf("%d samples, expected %d segments, got %d", test.samples, test.segments, len(segments))
+			continue
+		}
+		var next int64
+		for i, seg := range segments {
+			if seg.start != next || seg.start%frameSize != 0 || seg.end <= seg.start && test.samples > 0 {
+				t.Errorf("%d samples, unexpected segment %d, %+v", test.samples, i, seg)
+			}
+			if seg.first != (i == 0) || seg.last != (i == len(segments)-1) {
+				t.Errorf("%d samples, segment %d, unexpected first/last, %+v", test.samples, i, seg)
+			}
+			if !seg.first && seg.start-seg.inStart != _PARALLEL_PREROLL_FRAMES*frameSize {
+				t.Errorf("%d samples, segment %d, expected preroll, %+v", test.samples, i, seg)
+			}
+			if !seg.last && seg.inEnd != seg.end+_PARALLEL_POSTROLL_FRAMES*frameSize && seg.inEnd != test.samples {
+				t.Errorf("%d samples, segment %d, expected postroll, %+v", test.samples, i, seg)
+			}
+			next = seg.end
+		}
+		if next != test.samples {
+			t.Errorf("%d samples, expected all covered, got %d", test.samples, next)
+		}
+	}
+}
+
+func Test_ParallelEncode(t *testing.T) {
+	// 40 seconds of stereo, i.e., 3 segments of 256 frames at least
+	const sampleRate = 16000
+	pcm := new(bytes.Buffer)
+	for i := 0; i < sampleRate*40; i++ {
+		v := int16(10000 * math.Sin(2*math.Pi*440*float64(i)/sampleRate))
+		binary.Write(pcm, binary.LittleEndian, [2]int16{v, -v})
+	}
+	opts := EncodeOptions{
+		InSampleRate:    sampleRate,
+		InBitsPerSample: 16,
+		InNumChannels:   2,
+		OutSampleRate:   sampleRate,
+		OutMode:         MODE_JOINT_STEREO,
+		OutQuality:      5,
+	}
+	if segments := planSegments(int64(pcm.Len()/4), samplesPerFrame(sampleRate), 4); len(segments) < 3 {
+		t.Errorf("expected at least 3 segments, got %d", len(segments))
+	}
+
+	serial := new(bytes.Buffer)
+	if err := EncodeContext(context.Background(), serial, bytes.NewReader(pcm.Bytes()), opts); err != nil {
+		t.Errorf("cannot encode serially, %s", err.Error())
+		return
+	}
+	parallel := new(bytes.Buffer)
+	if err := ParallelEncode(bytes.NewReader(pcm.Bytes()), parallel, opts, 4); err != nil {
+		t.Errorf("cannot encode in parallel, %s", err.Error())
+		return
+	}
+	serialStats, err := mp3.Inspect(serial)
+	if err != nil {
+		t.Errorf("cannot inspect, %s", err.Error())
+		return
+	}
+	parallelStats, err := mp3.Inspect(parallel)
+	if err != nil {
+		t.Errorf("cannot inspect, %s", err.Error())
+		return
+	}
+	if diff := serialStats.Frames - parallelStats.Frames; diff < -1 || diff > 1 {
+		t.Errorf("expected %d frames (within one), got %d", serialStats.Frames, parallelStats.Frames)
+	}
+	if parallelStats.Skipped != 0 {
+		t.Errorf("expected frames stitched without junk, got %d bytes skipped", parallelStats.Skipped)
+	}
+}
+
+func Test_ParallelEncode_UnknownSize(t *testing.T) {
+	var src struct{ io.ReaderAt }
+	if err := ParallelEncode(src, new(bytes.Buffer), monoOptions(), 2); err != ErrUnknownSize {
+		t.Errorf("expected ErrUnknownSize, got %v", err)
+	}
+}
@@ -0,0 +1,170 @@
+package lame
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Helpers to turn raw PCM bytes into samples which could be fed into liblame
+
+type (
+	// how every single sample is represented
+	SampleFormat int
+
+	// returned if the bit depth of the input is not supported by the sample format
+	UnsupportedBitsPerSampleError struct {
+		Format        SampleFormat
+		BitsPerSample int
+	}
+)
+
+// let us define sample formats here
+const (
+	SAMPLE_FORMAT_INT   SampleFormat = iota // signed integer (unsigned if 8bit)
+	SAMPLE_FORMAT_FLOAT                     // IEEE float, ranging from -1 to 1
+)
+
+func (f SampleFormat) String() string {
+	switch f {
+	case SAMPLE_FORMAT_INT:
+		return "int"
+	case SAMPLE_FORMAT_FLOAT:
+		return "float"
+	default:
+		return fmt.Sprintf("SampleFormat(%d)", int(f))
+	}
+}
+
+func (e UnsupportedBitsPerSampleError) Error() string {
+	switch e.Format {
+	case SAMPLE_FORMAT_INT:
+		return fmt.Sprintf("unsupported bits per sample: %d, int PCM supports only 8, 16, 24 and 32", e.BitsPerSample)
+	case SAMPLE_FORMAT_FLOAT:
+		return fmt.Sprintf("unsupported bits per sample: %d, float PCM supports only 32 and 64", e.BitsPerSample)
+	default:
+		return fmt.Sprintf("unsupported sample format: %s", e.Format)
+	}
+}
+
+// the count of bytes taken by a single sample of the given format and depth
+func bytesPerSample(format SampleFormat, bitsPerSample int) (int, error) {
+	switch {
+	case format == SAMPLE_FORMAT_INT && (bitsPerSample == 8 || bitsPerSample == 16 || bitsPerSample == 24 || bitsPerSample == 32):
+		return bitsPerSample / 8, nil
+	case format == SAMPLE_FORMAT_FLOAT && (bitsPerSample == 32 || bitsPerSample == 64):
+		return bitsPerSample / 8, nil
+	default:
+		return 0, UnsupportedBitsPerSampleError{Format: format, BitsPerSample: bitsPerSample}
+	}
+}
+
+// decode integer PCM into full-scale int32 samples, which is exactly what lame_encode_buffer_int expects,
+// i.e., the most significant bit of each sample is always aligned to bit 31, so that no precision is lost.
+// 8bit samples are unsigned (as WAV files do), the others are signed.
+// samples must be able to hold len(p) / bytesPerSample elements; trailing bytes of an incomplete sample are ignored
+// returns the count of decoded samples
+func decodeIntSamples(p []byte, bitsPerSample int, bigEndian bool, samples []int32) (int, error) {
+	size, err := bytesPerSample(SAMPLE_FORMAT_INT, bitsPerSample)
+	if err != nil {
+		return 0, err
+	}
+	count := len(p) / size
+	shift := uint(32 - bitsPerSample)
+	for i := 0; i < count; i++ {
+		b := p[i*size : (i+1)*size]
+		var v uint32
+		for j := 0; j < size; j++ {
+			if bigEndian {
+				v = v<<8 | uint32(b[j])
+			} else {
+				v = v<<8 | uint32(b[size-1-j])
+			}
+		}
+		v <<= shift
+		if size == 1 {
+			v ^= 0x80000000 // unsigned -> signed
+		}
+		samples[i] = int32(v)
+	}
+	return count, nil
+}
+
+// decode 32bit IEEE float PCM
+// samples must be able to hold len(p) / 4 elements
+// returns the count of decoded samples
+func decodeFloat32Samples(p []byte, bigEndian bool, samples []float32) int {
+	var order binary.ByteOrder = binary.LittleEndian
+	if bigEndian {
+		order = binary.BigEndian
+	}
+	count := len(p) / 4
+	for i := 0; i < count; i++ {
+		samples[i] = math.Float32frombits(order.Uint32(p[i*4:]))
+	}
+	return count
+}
+
+// decode 64bit IEEE float PCM
+// samples must be able to hold len(p) / 8 elements
+// returns the count of decoded samples
+func decodeFloat64Samples(p []byte, bigEndian bool, samples []float64) int {
+	var order binary.ByteOrder = binary.LittleEndian
+	if bigEndian {
+		order = binary.BigEndian
+	}
+	count := len(p) / 8
+	for i := 0; i < count; i++ {
+		samples[i] = math.Float64frombits(order.Uint64(p[i*8:]))
+	}
+	return count
+}
+
+// decode PCM of any supported format into float32 samples ranging from -1 to 1, which is what lame_encode_buffer_ieee_float expects
+// samples must be able to hold len(p) / bytesPerSample elements
+// int and 64bit float samples are decoded into the int32s and float64s of scratch first, which are grown as needed
+// returns the count of decoded samples
+func decodeSamplesAsFloat32(p []byte, format SampleFormat, bitsPerSample int, bigEndian bool, samples []float32, scratch *writeBuffers) (int, error) {
+	size, err := bytesPerSample(format, bitsPerSample)
+	if err != nil {
+		return 0, err
+	}
+	count := len(p) / size
+	switch {
+	case format == SAMPLE_FORMAT_FLOAT && bitsPerSample == 32:
+		decodeFloat32Samples(p, bigEndian, samples)
+	case format == SAMPLE_FORMAT_FLOAT && bitsPerSample == 64:
+		scratch.float64s = growFloat64(scratch.float64s, count)
+		wide := scratch.float64s
+		decodeFloat64Samples(p, bigEndian, wide)
+		for i, v := range wide {
+			samples[i] = float32(v)
+		}
+	default:
+		scratch.int32s = growInt32(scratch.int32s, count)
+		ints := scratch.int32s
+		if _, err = decodeIntSamples(p, bitsPerSample, bigEndian, ints); err != nil {
+			return 0, err
+		}
+		for i, v := range ints {
+			samples[i] = float32(v) / (1 << 31)
+		}
+	}
+	return count, nil
+}
+
+// split interleaved stereo samples into left and right ones
+func deinterleaveInt32(samples, left, right []int32) {
+	for i := 0; i < len(left); i++ {
+		left[i] = samples[i*2]
+		right[i] = samples[i*2+1]
+	}
+}
+
+// split interleaved stereo float samples into left and right ones
+func deinterleaveFloat32(samples, left, right []float32) {
+	for i := 0; i < len(left); i++ {
+		left[i] = samples[i*2]
+		right[i] = samples[i*2+1]
+	}
+}
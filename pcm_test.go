@@ -0,0 +1,131 @@
+package lame
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+func Test_DecodeIntSamples(t *testing.T) {
+	tests := []struct {
+		name          string
+		bitsPerSample int
+		bigEndian     bool
+		data          []byte
+		expected      []int32
+	}{
+		{"8bit", 8, false, []byte{0x80, 0x00, 0xff, 0x81}, []int32{0, -0x80000000, 0x7f000000, 0x01000000}},
+		{"16bit-le", 16, false, []byte{0x01, 0x00, 0xff, 0xff, 0x00, 0x80}, []int32{0x00010000, -0x00010000, -0x80000000}},
+		{"16bit-be", 16, true, []byte{0x00, 0x01, 0xff, 0xff, 0x80, 0x00}, []int32{0x00010000, -0x00010000, -0x80000000}},
+		{"24bit-le", 24, false, []byte{0x56, 0x34, 0x12, 0xff, 0xff, 0xff}, []int32{0x12345600, -0x00000100}},
+		{"24bit-be", 24, true, []byte{0x12, 0x34, 0x56, 0xff, 0xff, 0xff}, []int32{0x12345600, -0x00000100}},
+		{"32bit-le", 32, false, []byte{0x78, 0x56, 0x34, 0x12, 0x00, 0x00, 0x00, 0x80}, []int32{0x12345678, -0x80000000}},
+		{"32bit-be", 32, true, []byte{0x12, 0x34, 0x56, 0x78, 0x80, 0x00, 0x00, 0x00}, []int32{0x12345678, -0x80000000}},
+		{"trailing", 16, false, []byte{0x01, 0x00, 0xff}, []int32{0x00010000}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			samples := make([]int32, len(test.data))
+			count, err := decodeIntSamples(test.data, test.bitsPerSample, test.bigEndian, samples)
+			if err != nil {
+				t.Errorf("cannot decode, %s", err.Error())
+				return
+			}
+			if count != len(test.expected) {
+				t.Errorf("expected %d samples, got %d", len(test.expected), count)
+				return
+			}
+			for i := range test.expected {
+				if samples[i] != test.expected[i] {
+					t.Errorf("sample#%d, expected %#x, got %#x", i, test.expected[i], samples[i])
+				}
+			}
+		})
+	}
+}
+
+func Test_DecodeIntSamples_Unsupported(t *testing.T) {
+	_, err := decodeIntSamples([]byte{0, 0}, 12, false, make([]int32, 2))
+	if _, ok := err.(UnsupportedBitsPerSampleError); !ok {
+		t.Errorf("expected UnsupportedBitsPerSampleError, got %#v", err)
+	}
+}
+
+func Test_BytesPerSample(t *testing.T) {
+	tests := []struct {
+		format        SampleFormat
+		bitsPerSample int
+		expected      int
+	}{
+		{SAMPLE_FORMAT_INT, 8, 1},
+		{SAMPLE_FORMAT_INT, 24, 3},
+		{SAMPLE_FORMAT_INT, 64, 0},
+		{SAMPLE_FORMAT_FLOAT, 16, 0},
+		{SAMPLE_FORMAT_FLOAT, 32, 4},
+		{SAMPLE_FORMAT_FLOAT, 64, 8},
+	}
+	for idx, test := range tests {
+		size, err := bytesPerSample(test.format, test.bitsPerSample)
+		if test.expected == 0 && err == nil {
+			t.Errorf("Case#%d, expected error for %s/%d", idx, test.format, test.bitsPerSample)
+		} else if size != test.expected {
+			t.Errorf("Case#%d, expected %d bytes, got %d", idx, test.expected, size)
+		}
+	}
+}
+
+func Test_DecodeFloatSamples(t *testing.T) {
+	expected := []float64{0, 0.5, -1, 0.25}
+	for _, bigEndian := range []bool{false, true} {
+		var order binary.ByteOrder = binary.LittleEndian
+		if bigEndian {
+			order = binary.BigEndian
+		}
+		p32 := make([]byte, len(expected)*4)
+		p64 := make([]byte, len(expected)*8)
+		for i, v := range expected {
+			order.PutUint32(p32[i*4:], math.Float32bits(float32(v)))
+			order.PutUint64(p64[i*8:], math.Float64bits(v))
+		}
+		samples32 := make([]float32, len(expected))
+		samples64 := make([]float64, len(expected))
+		if count := decodeFloat32Samples(p32, bigEndian, samples32); count != len(expected) {
+			t.Errorf("expected %d float32 samples, got %d", len(expected), count)
+		}
+		if count := decodeFloat64Samples(p64, bigEndian, samples64); count != len(expected) {
+			t.Errorf("expected %d float64 samples, got %d", len(expected), count)
+		}
+		for i, v := range expected {
+			if float64(samples32[i]) != v || samples64[i] != v {
+				t.Errorf("bigEndian=%v, sample#%d, expected %f, got %f/%f", bigEndian, i, v, samples32[i], samples64[i])
+			}
+		}
+	}
+}
+
+func Test_DecodeSamplesAsFloat32(t *testing.T) {
+	tests := []struct {
+		name          string
+		format        SampleFormat
+		bitsPerSample int
+		data          []byte
+		expected      []float32
+	}{
+		{"8bit", SAMPLE_FORMAT_INT, 8, []byte{0x80, 0x00, 0xc0}, []float32{0, -1, 0.5}},
+		{"16bit", SAMPLE_FORMAT_INT, 16, []byte{0x00, 0x40, 0x00, 0xc0}, []float32{0.5, -0.5}},
+		{"float64", SAMPLE_FORMAT_FLOAT, 64, []byte{0, 0, 0, 0, 0, 0, 0xe0, 0x3f}, []float32{0.5}},
+	}
+	for _, test := range tests {
+		samples := make([]float32, len(test.data))
+		count, err := decodeSamplesAsFloat32(test.data, test.format, test.bitsPerSample, false, samples, new(writeBuffers))
+		if err != nil || count != len(test.expected) {
+			t.Errorf("%s: unexpected count %d, err=%v", test.name, count, err)
+			continue
+		}
+		for i := range test.expected {
+			if samples[i] != test.expected[i] {
+				t.Errorf("%s: sample#%d, expected %f, got %f", test.name, i, test.expected[i], samples[i])
+			}
+		}
+	}
+}
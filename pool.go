@@ -0,0 +1,207 @@
+package lame
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// A pool of writers for high-throughput services, so that the native memory is released deterministically,
+// and the count of encoders alive is bounded
+// NOTE: only the Go buffers of a writer are reused. LAME can neither reset an encoder nor init its params twice,
+// so Put closes the native encoder and inits a new one (lame_close + lame_init), whose params are initialized
+// again with the options of the next Get, on the first Write.
+// Writers are shared among options of the same value, i.e., ChannelMatrix is compared by its coefficients, and
+// Tags are ignored.
+
+type (
+	// thread-safe
+	Pool struct {
+		mutex   sync.Mutex
+		maxSize int // count of writers alive, idle or in use
+		idle    map[poolKey][]*Writer
+		inUse   map[*Writer]struct{}
+		closed  bool
+		stats   PoolStats
+	}
+
+	// metrics of a pool
+	PoolStats struct {
+		Hits   uint64 // count of Get served by an idle writer
+		Misses uint64 // count of Get creating a new writer
+		InUse  int    // count of writers got, but not put back yet
+		Idle   int    // count of writers ready for Get
+	}
+
+	// options writers are shared by, without the pointers, which are compared by address otherwise
+	poolKey struct {
+		opts   EncodeOptions // without Tags and ChannelMatrix
+		matrix string        // coefficients of ChannelMatrix, empty if nil
+	}
+)
+
+var (
+	ErrPoolFull   = errors.New("pool is full, all writers are in use")
+	ErrPoolClosed = errors.New("pool is closed")
+)
+
+// create a pool holding at most maxSize writers, idle or in use, or any count if maxSize <= 0
+func NewPool(maxSize int) *Pool {
+	return &Pool{
+		maxSize: maxSize,
+		idle:    make(map[poolKey][]*Writer),
+		inUse:   make(map[*Writer]struct{}),
+	}
+}
+
+// get a writer with the given options, writing into output
+// an idle one of other options is released if the pool is full, or ErrPoolFull returned if none is idle
+// the options except Tags MUST NOT be changed, and the writer should be put back after Close
+func (p *Pool) Get(output io.Writer, opts EncodeOptions) (*Writer, error) {
+	p.mutex.Lock()
+	if p.closed {
+		p.mutex.Unlock()
+		return nil, ErrPoolClosed
+	}
+	key := newPoolKey(opts)
+	if writers := p.idle[key]; len(writers) > 0 {
+		w := writers[len(writers)-1]
+		p.removeIdle(key, len(writers)-1)
+		p.stats.Hits++
+		p.stats.InUse++
+		p.inUse[w] = struct{}{}
+		p.mutex.Unlock()
+		w.output = output
+		w.EncodeOptions = opts // with its own Tags, applied once params are initialized
+		return w, nil
+	}
+	var evicted *Writer
+	if p.maxSize > 0 && p.stats.InUse+p.stats.Idle >= p.maxSize {
+		if p.stats.Idle == 0 {
+			p.mutex.Unlock()
+			return nil, ErrPoolFull
+		}
+		evicted = p.evictOne()
+	}
+	// reserve the room, and create the writer outside the lock
+	p.stats.Misses++
+	p.stats.InUse++
+	p.mutex.Unlock()
+
+	if evicted != nil {
+		evicted.lame.Close()
+	}
+	w, err := NewWriter(output)
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if err != nil {
+		p.stats.Misses--
+		p.stats.InUse--
+		return nil, err
+	}
+	w.EncodeOptions = opts
+	p.inUse[w] = struct{}{}
+	return w, nil
+}
+
+// put the writer back, which must not be used any more
+// the residual data is discarded if it is not closed
+// putting a writer not in use, e.g., twice, does nothing
+func (p *Pool) Put(w *Writer) {
+	p.mutex.Lock()
+	_, ok := p.inUse[w]
+	delete(p.inUse, w)
+	closed := p.closed
+	p.mutex.Unlock()
+	if !ok {
+		return
+	}
+
+	// reset outside the lock, as it recreates the native encoder
+	var err error
+	if !closed {
+		err = w.reset()
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.stats.InUse--
+	if p.closed || err != nil {
+		w.lame.Close()
+		return
+	}
+	key := newPoolKey(w.EncodeOptions)
+	p.idle[key] = append(p.idle[key], w)
+	p.stats.Idle++
+}
+
+// release all the idle writers, and the ones in use once they are put back
+func (p *Pool) Close() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.closed = true
+	for key, writers := range p.idle {
+		for _, w := range writers {
+			w.lame.Close()
+		}
+		delete(p.idle, key)
+	}
+	p.stats.Idle = 0
+	return nil
+}
+
+func (p *Pool) Stats() PoolStats {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.stats
+}
+
+// remove the i-th idle writer of the given options
+func (p *Pool) removeIdle(key poolKey, i int) {
+	writers := p.idle[key]
+	writers[i] = writers[len(writers)-1]
+	if writers = writers[:len(writers)-1]; len(writers) == 0 {
+		delete(p.idle, key)
+	} else {
+		p.idle[key] = writers
+	}
+	p.stats.Idle--
+}
+
+// take an idle writer out to make room for another one, the caller releases it
+func (p *Pool) evictOne() *Writer {
+	for key, writers := range p.idle {
+		w := writers[0]
+		p.removeIdle(key, 0)
+		return w
+	}
+	return nil
+}
+
+// the key of writers shared by opts
+func newPoolKey(opts EncodeOptions) poolKey {
+	key := poolKey{opts: opts}
+	key.opts.Tags = nil
+	key.opts.ChannelMatrix = nil
+	if opts.ChannelMatrix != nil {
+		key.matrix = fmt.Sprint(*opts.ChannelMatrix) // float32 is formatted losslessly
+	}
+	return key
+}
+
+// get ready for the next stream, params are initialized on the first Write after the options are set
+func (w *Writer) reset() error {
+	if err := w.lame.reset(); err != nil {
+		return err
+	}
+	w.output = nil
+	w.startOffset = -1
+	w.mixer = nil
+	w.resamplers = nil
+	w.ctx = nil
+	w.samplesConsumed = 0
+	w.pending = w.pending[:0] // buffers are kept for the next one
+	return nil
+}
@@ -0,0 +1,198 @@
+package lame
+
+import (
+	"bytes"
+	"testing"
+)
+
+func Test_Pool(t *testing.T) {
+	pool := NewPool(4)
+	defer pool.Close()
+	opts := monoOptions()
+	pcm := make([]byte, 16000)
+
+	for i := 0; i < 3; i++ {
+		out := new(bytes.Buffer)
+		wr, err := pool.Get(out, opts)
+		if err != nil {
+			t.Errorf("cannot get writer, %s", err.Error())
+			return
+		}
+		if wr.output != out || wr.startOffset != -1 || wr.samplesConsumed != 0 {
+			t.Errorf("round %d, writer not reset", i)
+		}
+		if _, err = wr.Write(pcm); err != nil {
+			t.Errorf("round %d, cannot write, %s", i, err.Error())
+		}
+		if err = wr.Close(); err != nil {
+			t.Errorf("round %d, cannot close, %s", i, err.Error())
+		}
+		pool.Put(wr)
+	}
+	if stats := pool.Stats(); stats != (PoolStats{Hits: 2, Misses: 1, InUse: 0, Idle: 1}) {
+		t.Errorf("unexpected stats, %+v", stats)
+	}
+
+	// other options never take the idle one
+	stereo := opts
+	stereo.InNumChannels = 2
+	wr, err := pool.Get(new(bytes.Buffer), stereo)
+	if err != nil {
+		t.Errorf("cannot get writer, %s", err.Error())
+		return
+	}
+	if stats := pool.Stats(); stats != (PoolStats{Hits: 2, Misses: 2, InUse: 1, Idle: 1}) {
+		t.Errorf("unexpected stats, %+v", stats)
+	}
+	pool.Put(wr)
+}
+
+func Test_Pool_MaxSize(t *testing.T) {
+	pool := NewPool(1)
+	defer pool.Close()
+	opts := monoOptions()
+	stereo := opts
+	stereo.InNumChannels = 2
+
+	wr, err := pool.Get(new(bytes.Buffer), opts)
+	if err != nil {
+		t.Errorf("cannot get writer, %s", err.Error())
+		return
+	}
+	if _, err = pool.Get(new(bytes.Buffer), stereo); err != ErrPoolFull {
+		t.Errorf("expected ErrPoolFull, got %v", err)
+	}
+	pool.Put(wr)
+	// the idle one is released to make room
+	if wr2, err := pool.Get(new(bytes.Buffer), stereo); err != nil {
+		t.Errorf("cannot get writer, %s", err.Error())
+	} else {
+		if wr.lame.lgs != nil {
+			t.Errorf("expected the idle writer released")
+		}
+		pool.Put(wr2)
+	}
+	if stats := pool.Stats(); stats != (PoolStats{Hits: 0, Misses: 2, InUse: 0, Idle: 1}) {
+		t.Errorf("unexpected stats, %+v", stats)
+	}
+}
+
+func Test_Pool_Unbounded(t *testing.T) {
+	pool := NewPool(0)
+	defer pool.Close()
+	opts := monoOptions()
+
+	var writers []*Writer
+	for i := 0; i < 3; i++ {
+		wr, err := pool.Get(new(bytes.Buffer), opts)
+		if err != nil {
+			t.Errorf("round %d, cannot get writer, %v", i, err)
+			return
+		}
+		writers = append(writers, wr)
+	}
+	for _, wr := range writers {
+		pool.Put(wr)
+	}
+	if stats := pool.Stats(); stats != (PoolStats{Hits: 0, Misses: 3, InUse: 0, Idle: 3}) {
+		t.Errorf("unexpected stats, %+v", stats)
+	}
+}
+
+func Test_Pool_Close(t *testing.T) {
+	pool := NewPool(2)
+	opts := monoOptions()
+	idle, _ := pool.Get(new(bytes.Buffer), opts)
+	inUse, _ := pool.Get(new(bytes.Buffer), opts)
+	pool.Put(idle)
+	if err := pool.Close(); err != nil {
+		t.Errorf("cannot close pool, %s", err.Error())
+	}
+	if idle.lame.lgs != nil {
+		t.Errorf("expected the idle writer released on close")
+	}
+	pool.Put(inUse)
+	if inUse.lame.lgs != nil {
+		t.Errorf("expected the writer in use released once put back")
+	}
+	if _, err := pool.Get(new(bytes.Buffer), opts); err != ErrPoolClosed {
+		t.Errorf("expected ErrPoolClosed, got %v", err)
+	}
+	if stats := pool.Stats(); stats.InUse != 0 || stats.Idle != 0 {
+		t.Errorf("unexpected stats, %+v", stats)
+	}
+}
+
+func Test_Pool_Options(t *testing.T) {
+	pool := NewPool(4)
+	defer pool.Close()
+	opts := monoOptions()
+	opts.InNumChannels = 2
+	opts.Tags = &Tags{Title: "first"}
+	opts.ChannelMatrix = &ChannelMatrix{{0.5, 0.5}}
+
+	wr, err := pool.Get(new(bytes.Buffer), opts)
+	if err != nil {
+		t.Errorf("cannot get writer, %s", err.Error())
+		return
+	}
+	pool.Put(wr)
+
+	// the same coefficients in another matrix, and other tags, share the idle writer
+	other := opts
+	other.Tags = &Tags{Title: "second"}
+	other.ChannelMatrix = &ChannelMatrix{{0.5, 0.5}}
+	wr2, err := pool.Get(new(bytes.Buffer), other)
+	if err != nil {
+		t.Errorf("cannot get writer, %s", err.Error())
+		return
+	}
+	if wr2 != wr {
+		t.Errorf("expected the idle writer of the same options")
+	}
+	if wr2.Tags != other.Tags || wr2.ChannelMatrix != other.ChannelMatrix {
+		t.Errorf("expected the options of Get, got %+v", wr2.EncodeOptions)
+	}
+	if wr2.lame.paramUpdated {
+		t.Errorf("expected params initialized on the first write, with the tags of Get")
+	}
+	pool.Put(wr2)
+
+	// other coefficients never take it
+	other.ChannelMatrix = &ChannelMatrix{{1, 0}}
+	if wr3, err := pool.Get(new(bytes.Buffer), other); err != nil {
+		t.Errorf("cannot get writer, %s", err.Error())
+	} else {
+		if wr3 == wr {
+			t.Errorf("expected a new writer of another matrix")
+		}
+		pool.Put(wr3)
+	}
+	if stats := pool.Stats(); stats != (PoolStats{Hits: 1, Misses: 2, InUse: 0, Idle: 2}) {
+		t.Errorf("unexpected stats, %+v", stats)
+	}
+}
+
+func Test_Pool_PutTwice(t *testing.T) {
+	pool := NewPool(4)
+	defer pool.Close()
+	opts := monoOptions()
+	wr, err := pool.Get(new(bytes.Buffer), opts)
+	if err != nil {
+		t.Errorf("cannot get writer, %s", err.Error())
+		return
+	}
+	pool.Put(wr)
+	pool.Put(wr)
+	if stats := pool.Stats(); stats != (PoolStats{Hits: 0, Misses: 1, InUse: 0, Idle: 1}) {
+		t.Errorf("unexpected stats, %+v", stats)
+	}
+	// the writer is handed out once only
+	wr1, _ := pool.Get(new(bytes.Buffer), opts)
+	wr2, _ := pool.Get(new(bytes.Buffer), opts)
+	if wr1 == wr2 {
+		t.Errorf("expected different writers")
+	}
+	pool.Put(wr1)
+	pool.Put(wr2)
+}
@@ -0,0 +1,166 @@
+package lame
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Named encoding profiles, e.g., stored per customer as JSON
+// A profile is validated whenever it is marshalled or unmarshalled, and unknown JSON fields are rejected,
+// so that a typo in a config does not silently fall back to the defaults, e.g.,
+// {
+//   "name": "music-v2",
+//   "options": {
+//     "in_sample_rate": 44100, "in_bits_per_sample": 16, "in_num_channels": 2,
+//     "out_sample_rate": 44100, "out_mode": "joint_stereo", "out_quality": 2,
+//     "bitrate": {"mode": "vbr", "vbr_quality": 2}
+//   }
+// }
+// The input fields describe the PCM the profile expects. For other input, e.g., a WAV file, override them before use
+
+type (
+	Profile struct {
+		Name        string        `json:"name"`
+		Description string        `json:"description,omitempty"`
+		Options     EncodeOptions `json:"options"`
+	}
+)
+
+var (
+	ErrUnknownProfile   = errors.New("unknown profile")
+	ErrEmptyProfileName = errors.New("empty profile name")
+)
+
+// built-in profiles, see Profiles
+var builtinProfiles = []Profile{
+	{
+		Name:        "voice-16k-mono",
+		Description: "speech, e.g., calls and voice messages, 16kHz mono CBR 32kbps",
+		Options: EncodeOptions{
+			InSampleRate:    16000,
+			InBitsPerSample: 16,
+			InNumChannels:   1,
+			OutSampleRate:   16000,
+			OutMode:         MODE_MONO,
+			OutQuality:      5,
+			Bitrate:         BitrateOptions{Mode: BITRATE_MODE_CBR, Kbps: 32},
+			HighpassFreq:    Int(80),
+		},
+	},
+	{
+		Name:        "podcast-64k-mono",
+		Description: "spoken word from stereo 44.1kHz, mixed down to mono CBR 64kbps",
+		Options: EncodeOptions{
+			InSampleRate:    44100,
+			InBitsPerSample: 16,
+			InNumChannels:   2,
+			OutSampleRate:   44100,
+			OutMode:         MODE_MONO,
+			OutQuality:      2,
+			Bitrate:         BitrateOptions{Mode: BITRATE_MODE_CBR, Kbps: 64},
+		},
+	},
+	{
+		Name:        "music-v0",
+		Description: "music, stereo 44.1kHz, VBR of the highest quality (V0)",
+		Options: EncodeOptions{
+			InSampleRate:    44100,
+			InBitsPerSample: 16,
+			InNumChannels:   2,
+			OutSampleRate:   44100,
+			OutMode:         MODE_JOINT_STEREO,
+			OutQuality:      2,
+			Bitrate:         BitrateOptions{Mode: BITRATE_MODE_VBR, VBRQuality: 0},
+		},
+	},
+	{
+		Name:        "music-v2",
+		Description: "music, stereo 44.1kHz, VBR of transparent quality for most listeners (V2)",
+		Options: EncodeOptions{
+			InSampleRate:    44100,
+			InBitsPerSample: 16,
+			InNumChannels:   2,
+			OutSampleRate:   44100,
+			OutMode:         MODE_JOINT_STEREO,
+			OutQuality:      2,
+			Bitrate:         BitrateOptions{Mode: BITRATE_MODE_VBR, VBRQuality: 2},
+		},
+	},
+	{
+		Name:        "music-cbr-320",
+		Description: "music, stereo 44.1kHz, CBR 320kbps",
+		Options: EncodeOptions{
+			InSampleRate:    44100,
+			InBitsPerSample: 16,
+			InNumChannels:   2,
+			OutSampleRate:   44100,
+			OutMode:         MODE_JOINT_STEREO,
+			OutQuality:      2,
+			Bitrate:         BitrateOptions{Mode: BITRATE_MODE_CBR, Kbps: 320},
+		},
+	},
+}
+
+// all the built-in profiles
+func Profiles() []Profile {
+	return append([]Profile(nil), builtinProfiles...)
+}
+
+// the built-in profile of the given name, e.g., "voice-16k-mono" or "music-v0"
+func LookupProfile(name string) (Profile, error) {
+	for _, profile := range builtinProfiles {
+		if profile.Name == name {
+			return profile, nil
+		}
+	}
+	return Profile{}, fmt.Errorf("%w %q", ErrUnknownProfile, name)
+}
+
+// check the name and the options, see EncodeOptions.Validate
+func (p *Profile) Validate() error {
+	if p.Name == "" {
+		return ErrEmptyProfileName
+	}
+	if err := p.Options.Validate(); err != nil {
+		return fmt.Errorf("profile %q, %w", p.Name, err)
+	}
+	return nil
+}
+
+// a writer with the options of the profile
+func (p *Profile) NewWriter(output io.Writer) (*Writer, error) {
+	w, err := NewWriter(output)
+	if err != nil {
+		return nil, err
+	}
+	w.EncodeOptions = p.Options
+	return w, nil
+}
+
+// validated before marshalled
+func (p Profile) MarshalJSON() ([]byte, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	type profile Profile // without MarshalJSON
+	return json.Marshal(profile(p))
+}
+
+// unknown fields are rejected, and the profile is validated
+func (p *Profile) UnmarshalJSON(data []byte) error {
+	type profile Profile // without UnmarshalJSON
+	var decoded profile
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&decoded); err != nil {
+		return err
+	}
+	if err := (*Profile)(&decoded).Validate(); err != nil {
+		return err
+	}
+	*p = Profile(decoded)
+	return nil
+}
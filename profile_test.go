@@ -0,0 +1,87 @@
+package lame
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_Profile_Builtin(t *testing.T) {
+	for _, name := range []string{"voice-16k-mono", "music-v0"} {
+		if _, err := LookupProfile(name); err != nil {
+			t.Errorf("expected profile %s, got %v", name, err)
+		}
+	}
+	if _, err := LookupProfile("music-v11"); !errors.Is(err, ErrUnknownProfile) {
+		t.Errorf("expected ErrUnknownProfile, got %v", err)
+	}
+	for _, profile := range Profiles() {
+		if err := profile.Validate(); err != nil {
+			t.Errorf("%s, expected valid, got %s", profile.Name, err.Error())
+		}
+	}
+}
+
+func Test_Profile_JSON(t *testing.T) {
+	for _, profile := range Profiles() {
+		profile.Options.Tags = &Tags{Comment: "customer 42", Version: ID3_V2_ONLY}
+		profile.Options.Copyright = Bool(true)
+		data, err := json.Marshal(profile)
+		if err != nil {
+			t.Errorf("%s, cannot marshal, %s", profile.Name, err.Error())
+			continue
+		}
+		var decoded Profile
+		if err = json.Unmarshal(data, &decoded); err != nil {
+			t.Errorf("%s, cannot unmarshal, %s", profile.Name, err.Error())
+			continue
+		}
+		if !reflect.DeepEqual(profile, decoded) {
+			t.Errorf("%s, expected to round trip, got %+v from %s", profile.Name, decoded, data)
+		}
+	}
+
+	var profile Profile
+	data := `{
+		"name": "music-v2",
+		"options": {
+			"in_sample_rate": 44100, "in_bits_per_sample": 16, "in_num_channels": 2,
+			"out_sample_rate": 44100, "out_mode": "joint_stereo", "out_quality": 2,
+			"bitrate": {"mode": "vbr", "vbr_quality": 2},
+			"lowpass_freq": 19000, "original": null
+		}
+	}`
+	if err := json.Unmarshal([]byte(data), &profile); err != nil {
+		t.Errorf("cannot unmarshal, %s", err.Error())
+	} else if profile.Options.OutMode != MODE_JOINT_STEREO || profile.Options.Bitrate.Mode != BITRATE_MODE_VBR ||
+		profile.Options.LowpassFreq != Int(19000) || profile.Options.Original.Set {
+		t.Errorf("unexpected profile, %+v", profile)
+	}
+}
+
+func Test_Profile_JSON_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		contains string
+	}{
+		{"unknown field", `{"name": "x", "options": {"out_sampel_rate": 44100}}`, "out_sampel_rate"},
+		{"unknown mode", `{"name": "x", "options": {"out_mode": "surround"}}`, "surround"},
+		{"invalid options", `{"name": "x", "options": {"in_sample_rate": 44100, "in_bits_per_sample": 16,
+			"in_num_channels": 1, "out_sample_rate": 11025, "out_mode": "mono", "out_quality": 12,
+			"bitrate": {"mode": "cbr", "kbps": 320}}}`, "OutQuality"},
+		{"no name", `{"options": {}}`, ErrEmptyProfileName.Error()},
+	}
+	for _, test := range tests {
+		var profile Profile
+		err := json.Unmarshal([]byte(test.data), &profile)
+		if err == nil || !strings.Contains(err.Error(), test.contains) {
+			t.Errorf("%s, expected an error about %s, got %v", test.name, test.contains, err)
+		}
+	}
+	if _, err := json.Marshal(Profile{Name: "invalid"}); err == nil {
+		t.Errorf("expected invalid profile not marshalled")
+	}
+}
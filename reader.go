@@ -0,0 +1,110 @@
+package lame
+
+import (
+	"bytes"
+	"io"
+)
+
+// Pull-based encoding, e.g., to pass an encoder as the body of a http.Response, or into an upload API taking an
+// io.Reader, without an io.Pipe and a goroutine, e.g.,
+// rd, err := lame.NewEncodingReader(pcm, opts)
+// defer rd.Close()
+// io.Copy(w, rd)
+// PCM is read from the source just enough to produce some MP3, and flushed once the source reaches EOF
+
+type (
+	// an io.ReadCloser of MP3, encoded lazily from the PCM of src
+	EncodingReader struct {
+		src    io.Reader
+		writer *Writer
+		// encoded data not read yet, the output of writer
+		out bytes.Buffer
+		// PCM read from src, reused across reads
+		buf []byte
+		// true once src reaches EOF and the residual data is flushed
+		done bool
+		// the first error met, returned from then on
+		err error
+	}
+)
+
+// create a reader of MP3 encoded with opts from the PCM of src
+// the options are validated before returning, see EncodeOptions.Validate
+// src is not closed by the reader
+func NewEncodingReader(src io.Reader, opts EncodeOptions) (*EncodingReader, error) {
+	r := &EncodingReader{src: src}
+	w, err := NewWriter(&r.out)
+	if err != nil {
+		return nil, err
+	}
+	w.EncodeOptions = opts
+	if err = w.ForceUpdateParams(); err != nil {
+		w.lame.Close() // released without flushing
+		return nil, err
+	}
+	size, err := w.inputBufSize(1) // just enough to produce a frame
+	if err != nil {
+		w.lame.Close() // released without flushing
+		return nil, err
+	}
+	r.writer = w
+	r.buf = make([]byte, size)
+	return r, nil
+}
+
+// read the encoded data, encoding more PCM from src as needed
+// returns io.EOF once all the data is read, including the residual one flushed at the EOF of src
+func (r *EncodingReader) Read(p []byte) (n int, err error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	for r.out.Len() == 0 && !r.done && r.err == nil {
+		r.err = r.encode()
+	}
+	if r.out.Len() > 0 {
+		return r.out.Read(p)
+	}
+	if r.err != nil {
+		return 0, r.err
+	}
+	return 0, io.EOF
+}
+
+// encode the next chunk of src, or flush the residual data at EOF
+func (r *EncodingReader) encode() error {
+	n, readErr := r.src.Read(r.buf)
+	if _, err := r.writer.Write(r.buf[:n]); err != nil {
+		return err
+	}
+	if readErr == io.EOF {
+		r.done = true
+		return r.writer.Close()
+	}
+	return readErr
+}
+
+// encode the whole src into dst, without a buffer in between, returns the count of bytes written
+// the data read by Read before is not written again
+func (r *EncodingReader) WriteTo(dst io.Writer) (n int64, err error) {
+	for {
+		written, writeErr := r.out.WriteTo(dst)
+		n += written
+		if writeErr != nil {
+			return n, writeErr
+		}
+		if r.err != nil || r.done {
+			return n, r.err
+		}
+		r.err = r.encode()
+	}
+}
+
+// release the native encoder, dropping the data not read yet, so that Read returns ErrClosed afterwards
+// closing more than once does nothing
+func (r *EncodingReader) Close() error {
+	r.out.Reset()
+	if r.err == nil {
+		r.err = ErrClosed
+	}
+	return r.writer.lame.Close() // without flushing
+}
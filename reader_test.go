@@ -0,0 +1,139 @@
+package lame
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing"
+	"testing/iotest"
+)
+
+func Test_EncodingReader(t *testing.T) {
+	data, err := ioutil.ReadFile("res/1chan_s16ple.raw")
+	if err != nil {
+		t.Errorf("cannot read file, %s", err.Error())
+		return
+	}
+	expected := new(bytes.Buffer)
+	wr, _ := NewWriter(expected)
+	wr.EncodeOptions = monoOptions()
+	if _, err = wr.Write(data); err != nil {
+		t.Errorf("cannot write, %s", err.Error())
+	}
+	wr.Close()
+
+	tests := []struct {
+		name string
+		read func(*EncodingReader) ([]byte, error)
+	}{
+		{"read all", func(rd *EncodingReader) ([]byte, error) {
+			return ioutil.ReadAll(rd)
+		}},
+		{"one byte at a time", func(rd *EncodingReader) ([]byte, error) {
+			return ioutil.ReadAll(iotest.OneByteReader(rd))
+		}},
+		{"write to", func(rd *EncodingReader) ([]byte, error) {
+			out := new(bytes.Buffer)
+			n, err := io.Copy(out, rd)
+			if n != int64(out.Len()) {
+				t.Errorf("write to, expected %d bytes written, got %d", out.Len(), n)
+			}
+			return out.Bytes(), err
+		}},
+	}
+	for _, test := range tests {
+		// PCM is read in halves, so that frames are split across reads
+		rd, err := NewEncodingReader(iotest.HalfReader(bytes.NewReader(data)), monoOptions())
+		if err != nil {
+			t.Errorf("%s, cannot create encoding reader, %s", test.name, err.Error())
+			continue
+		}
+		actual, err := test.read(rd)
+		if err != nil {
+			t.Errorf("%s, cannot read, %s", test.name, err.Error())
+		}
+		if !bytes.Equal(expected.Bytes(), actual) {
+			t.Errorf("%s, expected the same mp3 as Writer, got %d and %d bytes", test.name, expected.Len(), len(actual))
+		}
+		if rd.writer.samplesConsumed != int64(len(data)/2) {
+			t.Errorf("%s, expected %d samples consumed, got %d", test.name, len(data)/2, rd.writer.samplesConsumed)
+		}
+		rd.Close()
+	}
+}
+
+func Test_EncodingReader_InvalidOptions(t *testing.T) {
+	opts := monoOptions()
+	opts.OutQuality = 10
+	if _, err := NewEncodingReader(bytes.NewReader(nil), opts); !errors.Is(err, ErrInvalidQuality) {
+		t.Errorf("expected ErrInvalidQuality, got %v", err)
+	}
+}
+
+func Test_EncodingReader_SourceError(t *testing.T) {
+	// fails on the second read of the source
+	rd, err := NewEncodingReader(iotest.TimeoutReader(bytes.NewReader(make([]byte, 64*1024))), monoOptions())
+	if err != nil {
+		t.Errorf("cannot create encoding reader, %s", err.Error())
+		return
+	}
+	defer rd.Close()
+	if _, err = ioutil.ReadAll(rd); err != iotest.ErrTimeout {
+		t.Errorf("expected iotest.ErrTimeout, got %v", err)
+	}
+}
+
+func Test_EncodingReader_Close(t *testing.T) {
+	rd, err := NewEncodingReader(bytes.NewReader(make([]byte, 64*1024)), monoOptions())
+	if err != nil {
+		t.Errorf("cannot create encoding reader, %s", err.Error())
+		return
+	}
+	if _, err = rd.Read(make([]byte, 16)); err != nil && err != io.EOF {
+		t.Errorf("cannot read, %s", err.Error())
+	}
+	for i := 0; i < 2; i++ {
+		if err = rd.Close(); err != nil {
+			t.Errorf("cannot close, %s", err.Error())
+		}
+	}
+	if _, err = rd.Read(make([]byte, 16)); err != ErrClosed {
+		t.Errorf("expected ErrClosed after closed, got %v", err)
+	}
+}
+
+// reading MP3 through the generic loop of io.Copy, as before WriteTo
+func Benchmark_EncodingReader_Read(b *testing.B) {
+	benchmarkEncodingReaderCopy(b, func(rd *EncodingReader) io.Reader {
+		return struct{ io.Reader }{rd}
+	})
+}
+
+func Benchmark_EncodingReader_WriteTo(b *testing.B) {
+	benchmarkEncodingReaderCopy(b, func(rd *EncodingReader) io.Reader {
+		return rd
+	})
+}
+
+func benchmarkEncodingReaderCopy(b *testing.B, src func(*EncodingReader) io.Reader) {
+	data, err := ioutil.ReadFile("res/1chan_s16ple.raw")
+	if err != nil {
+		b.Fatalf("cannot read file, %s", err.Error())
+	}
+	// a writer without ReadFrom, so that io.Copy takes WriteTo, or the generic loop
+	dst := struct{ io.Writer }{ioutil.Discard}
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rd, err := NewEncodingReader(bytes.NewReader(data), monoOptions())
+		if err != nil {
+			b.Fatalf("cannot create encoding reader, %s", err.Error())
+		}
+		if _, err = io.Copy(dst, src(rd)); err != nil {
+			b.Fatalf("cannot copy, %s", err.Error())
+		}
+		rd.Close()
+	}
+}
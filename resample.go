@@ -0,0 +1,177 @@
+package lame
+
+import (
+	"math"
+)
+
+// A pure-Go windowed-sinc resampler, converting sample rates LAME rejects (e.g., 96k, 88.2k, 192k, 37.8k)
+// into the ones it supports
+// ref: https://ccrma.stanford.edu/~jos/resample/
+
+type (
+	// the trade-off between speed and quality of resampling
+	ResampleQuality int
+
+	// streaming resampler of a single channel
+	// NOT thread-safe!
+	Resampler struct {
+		inRate, outRate int64
+
+		kernel      []float64 // right half of the windowed sinc, sampled _RESAMPLE_KERNEL_OVERSAMPLE times per input sample
+		kernelWidth float64   // half width of the kernel, in input samples
+		support     int64     // count of input samples taken on each side of an output sample
+
+		history []float32 // input samples not yet consumed
+		base    int64     // absolute index of history[0]
+		inCount int64     // count of input samples so far
+		outNext int64     // absolute index of the next output sample
+		out     []float32 // output buffer, reused between calls
+	}
+)
+
+// let us define resample qualities here
+const (
+	RESAMPLE_QUALITY_DEFAULT ResampleQuality = iota // same with RESAMPLE_QUALITY_MEDIUM
+	RESAMPLE_QUALITY_LOW                            // 8 zero crossings, passband up to 85% of Nyquist
+	RESAMPLE_QUALITY_MEDIUM                         // 16 zero crossings, passband up to 90% of Nyquist
+	RESAMPLE_QUALITY_HIGH                           // 32 zero crossings, passband up to 95% of Nyquist
+)
+
+const (
+	_RESAMPLE_KERNEL_OVERSAMPLE = 256 // resolution of the kernel table
+)
+
+// sample rates supported by LAME
+var supportedSampleRates = []int{8000, 11025, 12000, 16000, 22050, 24000, 32000, 44100, 48000}
+
+// the sample rate supported by LAME which is the nearest to the given one
+func NearestSampleRate(sampleRate int) int {
+	nearest := supportedSampleRates[0]
+	for _, rate := range supportedSampleRates {
+		if abs(rate-sampleRate) < abs(nearest-sampleRate) {
+			nearest = rate
+		}
+	}
+	return nearest
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// zero crossings on each side, and the passband relative to Nyquist
+func (q ResampleQuality) params() (zeroCrossings int, rolloff float64) {
+	switch q {
+	case RESAMPLE_QUALITY_LOW:
+		return 8, 0.85
+	case RESAMPLE_QUALITY_HIGH:
+		return 32, 0.95
+	default:
+		return 16, 0.9
+	}
+}
+
+// create a resampler converting inRate into outRate, both must be positive
+func NewResampler(inRate, outRate int, quality ResampleQuality) *Resampler {
+	zeroCrossings, rolloff := quality.params()
+	// cutoff in cycles per input sample, below the Nyquist of both rates
+	cutoff := 0.5 * rolloff * math.Min(1, float64(outRate)/float64(inRate))
+	r := &Resampler{
+		inRate:      int64(inRate),
+		outRate:     int64(outRate),
+		kernelWidth: float64(zeroCrossings) / (2 * cutoff),
+	}
+	r.support = int64(math.Ceil(r.kernelWidth))
+	r.kernel = make([]float64, int(r.kernelWidth*_RESAMPLE_KERNEL_OVERSAMPLE)+2)
+	for i := range r.kernel {
+		x := float64(i) / _RESAMPLE_KERNEL_OVERSAMPLE
+		r.kernel[i] = 2 * cutoff * sinc(2*cutoff*x) * blackman(x/r.kernelWidth)
+	}
+	// there is nothing before the first sample
+	r.history = make([]float32, r.support)
+	r.base = -r.support
+	return r
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+// blackman window, u ranges from -1 to 1
+func blackman(u float64) float64 {
+	if u <= -1 || u >= 1 {
+		return 0
+	}
+	return 0.42 + 0.5*math.Cos(math.Pi*u) + 0.08*math.Cos(2*math.Pi*u)
+}
+
+// value of the kernel at x input samples away from the center, linearly interpolated
+func (r *Resampler) kernelAt(x float64) float64 {
+	pos := math.Abs(x) * _RESAMPLE_KERNEL_OVERSAMPLE
+	i := int(pos)
+	if i+1 >= len(r.kernel) {
+		return 0
+	}
+	frac := pos - float64(i)
+	return r.kernel[i]*(1-frac) + r.kernel[i+1]*frac
+}
+
+// resample the given input, returns the output available so far
+// NOTE: the returned slice is reused by the following calls
+func (r *Resampler) Process(in []float32) []float32 {
+	r.history = append(r.history, in...)
+	r.inCount += int64(len(in))
+	r.out = r.out[:0]
+	r.produce(r.base + int64(len(r.history)))
+	return r.out
+}
+
+// resample the rest of the input, which is held back as the kernel needs the input after it
+// NOTE: the returned slice is reused by the following calls
+func (r *Resampler) Flush() []float32 {
+	r.history = append(r.history, make([]float32, r.support+1)...)
+	r.out = r.out[:0]
+	// outputs up to the last input sample
+	total := (r.inCount*r.outRate + r.inRate - 1) / r.inRate
+	for r.outNext < total && r.produceOne(r.base+int64(len(r.history))) {
+	}
+	return r.out
+}
+
+// produce as many outputs as the input (up to end, exclusively) allows, and drop the input no longer needed
+func (r *Resampler) produce(end int64) {
+	for r.produceOne(end) {
+	}
+	// the earliest input the next output needs
+	center := r.outNext * r.inRate / r.outRate
+	if drop := center - r.support + 1 - r.base; drop > 0 {
+		if drop > int64(len(r.history)) {
+			drop = int64(len(r.history))
+		}
+		r.history = r.history[:copy(r.history, r.history[drop:])]
+		r.base += drop
+	}
+}
+
+// produce the next output if the input is enough
+func (r *Resampler) produceOne(end int64) bool {
+	num := r.outNext * r.inRate
+	center := num / r.outRate
+	frac := float64(num%r.outRate) / float64(r.outRate)
+	if center+r.support >= end {
+		return false
+	}
+	var sum float64
+	for j := center - r.support + 1; j <= center+r.support; j++ {
+		sum += float64(r.history[j-r.base]) * r.kernelAt(float64(j-center)-frac)
+	}
+	r.out = append(r.out, float32(sum))
+	r.outNext++
+	return true
+}
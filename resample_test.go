@@ -0,0 +1,119 @@
+package lame
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+func sineWave(sampleRate int, freq float64, count int) []float32 {
+	samples := make([]float32, count)
+	for i := range samples {
+		samples[i] = float32(0.5 * math.Sin(2*math.Pi*freq*float64(i)/float64(sampleRate)))
+	}
+	return samples
+}
+
+func Test_NearestSampleRate(t *testing.T) {
+	tests := []struct {
+		rate     int
+		expected int
+	}{
+		{96000, 48000},
+		{88200, 48000},
+		{192000, 48000},
+		{37800, 32000},
+		{44100, 44100},
+		{11000, 11025},
+		{4000, 8000},
+	}
+	for _, test := range tests {
+		if actual := NearestSampleRate(test.rate); actual != test.expected {
+			t.Errorf("rate %d, expected %d, got %d", test.rate, test.expected, actual)
+		}
+	}
+}
+
+func Test_Resampler(t *testing.T) {
+	tests := []struct {
+		inRate, outRate int
+		quality         ResampleQuality
+	}{
+		{96000, 48000, RESAMPLE_QUALITY_DEFAULT},
+		{88200, 48000, RESAMPLE_QUALITY_LOW},
+		{37800, 32000, RESAMPLE_QUALITY_HIGH},
+		{7000, 8000, RESAMPLE_QUALITY_MEDIUM},
+	}
+	for _, test := range tests {
+		in := sineWave(test.inRate, 440, test.inRate/2)
+		r := NewResampler(test.inRate, test.outRate, test.quality)
+		var out []float32
+		// feed in odd-sized chunks
+		for i := 0; i < len(in); i += 333 {
+			end := i + 333
+			if end > len(in) {
+				end = len(in)
+			}
+			out = append(out, r.Process(in[i:end])...)
+		}
+		out = append(out, r.Flush()...)
+		if len(out) != test.outRate/2 {
+			t.Errorf("%d->%d, expected %d samples, got %d", test.inRate, test.outRate, test.outRate/2, len(out))
+			continue
+		}
+		// skip both ends, where the kernel sees the silence out of the input
+		expected := sineWave(test.outRate, 440, len(out))
+		for i := len(out) / 10; i < len(out)*9/10; i++ {
+			if math.Abs(float64(expected[i]-out[i])) > 1e-2 {
+				t.Errorf("%d->%d, sample %d, expected %f, got %f", test.inRate, test.outRate, i, expected[i], out[i])
+				break
+			}
+		}
+	}
+}
+
+func Test_Resampler_Lowpass(t *testing.T) {
+	// 30kHz is beyond the Nyquist of 48k, and must not alias into the output
+	in := sineWave(96000, 30000, 9600)
+	r := NewResampler(96000, 48000, RESAMPLE_QUALITY_DEFAULT)
+	out := append(r.Process(in), r.Flush()...)
+	for i := len(out) / 10; i < len(out)*9/10; i++ {
+		if math.Abs(float64(out[i])) > 1e-2 {
+			t.Errorf("sample %d, expected silence, got %f", i, out[i])
+			break
+		}
+	}
+}
+
+func Test_Encoder_Resample(t *testing.T) {
+	samples := sineWave(96000, 440, 9600)
+	pcm := new(bytes.Buffer)
+	for _, s := range samples {
+		binary.Write(pcm, binary.LittleEndian, int16(s*math.MaxInt16))
+		binary.Write(pcm, binary.LittleEndian, int16(s*math.MaxInt16))
+	}
+	wr, err := NewWriter(new(bytes.Buffer))
+	if err != nil {
+		t.Errorf("cannot create lame writer, %s", err.Error())
+		return
+	}
+	wr.InSampleRate = 96000
+	wr.OutSampleRate = 48000
+	wr.OutMode = MODE_STEREO
+
+	if n, err := wr.Write(pcm.Bytes()); err != nil {
+		t.Errorf("cannot write, %s", err.Error())
+	} else if n != pcm.Len() {
+		t.Errorf("expected %d bytes written, got %d", pcm.Len(), n)
+	}
+	if len(wr.resamplers) != 2 {
+		t.Errorf("expected 2 resamplers, got %d", len(wr.resamplers))
+	}
+	if rate, _ := wr.lame.GetInSampleRate(); rate != 48000 {
+		t.Errorf("expected lame to take 48000Hz, got %d", rate)
+	}
+	if err = wr.Close(); err != nil {
+		t.Errorf("cannot close, %s", err.Error())
+	}
+}
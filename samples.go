@@ -0,0 +1,237 @@
+package lame
+
+import "errors"
+
+// Typed sample-level writes, for callers who already hold decoded samples, e.g.,
+// wr.WriteInt16(interleaved)
+// wr.WritePlanarFloat32(left, right)
+// Samples are taken as they are, regardless of InBitsPerSample, InSampleFormat and InBigEndian, while
+// InNumChannels and InSampleRate still describe them. The mp3 buffer is sized by the 1.25*n+7200 rule of LAME,
+// and channels are mixed and resampled as Write does
+
+type (
+	// writes samples of a type rather than bytes
+	// interleaved samples hold a sample of each channel per frame, i.e., L R L R ... if stereo
+	// planar ones take mono (right is nil) or stereo input only
+	SampleWriter interface {
+		WriteInt16(interleaved []int16) error
+		WriteInt32(interleaved []int32) error     // full-scale, i.e., ranging from math.MinInt32 to math.MaxInt32
+		WriteFloat32(interleaved []float32) error // in range [-1, 1]
+		WritePlanarInt16(left, right []int16) error
+		WritePlanarInt32(left, right []int32) error
+		WritePlanarFloat32(left, right []float32) error
+	}
+)
+
+var (
+	ErrPlanarChannels        = errors.New("planar samples are supported for mono and stereo input only")
+	ErrChannelLengthMismatch = errors.New("channels of different lengths, expected right as long as left, or nil if mono")
+	ErrPendingBytes          = errors.New("an incomplete frame is pending from Write, expected the rest of it before samples")
+)
+
+var _ SampleWriter = (*Writer)(nil)
+
+func (w *Writer) WriteInt16(interleaved []int16) error {
+	frames, err := w.interleavedFrames(len(interleaved))
+	if err != nil {
+		return err
+	}
+	return w.writeSamples(frames, func() ([]byte, error) {
+		if w.mixed() {
+			samples, left, right := w.floatBuffers(len(interleaved))
+			int16ToFloat32(interleaved, samples)
+			return w.encodeMixed(samples, left, right)
+		}
+		if w.InNumChannels == 1 {
+			return w.mp3Result(w.lame.EncodeInt16(interleaved, interleaved, w.buffers.mp3))
+		}
+		return w.mp3Result(w.lame.EncodeInt16Interleaved(interleaved, w.buffers.mp3))
+	})
+}
+
+func (w *Writer) WriteInt32(interleaved []int32) error {
+	frames, err := w.interleavedFrames(len(interleaved))
+	if err != nil {
+		return err
+	}
+	return w.writeSamples(frames, func() ([]byte, error) {
+		if w.mixed() {
+			samples, left, right := w.floatBuffers(len(interleaved))
+			int32ToFloat32(interleaved, samples)
+			return w.encodeMixed(samples, left, right)
+		}
+		if w.InNumChannels == 1 {
+			return w.mp3Result(w.lame.EncodeInt32(interleaved, interleaved, w.buffers.mp3))
+		}
+		return w.mp3Result(w.lame.EncodeInt32Interleaved(interleaved, w.buffers.mp3))
+	})
+}
+
+func (w *Writer) WriteFloat32(interleaved []float32) error {
+	frames, err := w.interleavedFrames(len(interleaved))
+	if err != nil {
+		return err
+	}
+	return w.writeSamples(frames, func() ([]byte, error) {
+		if w.mixed() {
+			w.buffers.float32s = growFloat32(w.buffers.float32s, frames*2)
+			return w.encodeMixed(interleaved, w.buffers.float32s[:frames], w.buffers.float32s[frames:])
+		}
+		if w.InNumChannels == 1 {
+			return w.mp3Result(w.lame.EncodeFloat32(interleaved, interleaved, w.buffers.mp3))
+		}
+		return w.mp3Result(w.lame.EncodeFloat32Interleaved(interleaved, w.buffers.mp3))
+	})
+}
+
+func (w *Writer) WritePlanarInt16(left, right []int16) error {
+	frames, err := w.planarFrames(len(left), len(right))
+	if err != nil {
+		return err
+	}
+	if len(right) == 0 { // mono
+		right = left
+	}
+	return w.writeSamples(frames, func() ([]byte, error) {
+		if w.mixed() {
+			samples, l, r := w.floatBuffers(frames * w.InNumChannels)
+			int16ToFloat32(left, l)
+			int16ToFloat32(right, r)
+			interleaveFloat32(l, r, samples)
+			return w.encodeMixed(samples, l, r)
+		}
+		return w.mp3Result(w.lame.EncodeInt16(left, right, w.buffers.mp3))
+	})
+}
+
+func (w *Writer) WritePlanarInt32(left, right []int32) error {
+	frames, err := w.planarFrames(len(left), len(right))
+	if err != nil {
+		return err
+	}
+	if len(right) == 0 { // mono
+		right = left
+	}
+	return w.writeSamples(frames, func() ([]byte, error) {
+		if w.mixed() {
+			samples, l, r := w.floatBuffers(frames * w.InNumChannels)
+			int32ToFloat32(left, l)
+			int32ToFloat32(right, r)
+			interleaveFloat32(l, r, samples)
+			return w.encodeMixed(samples, l, r)
+		}
+		return w.mp3Result(w.lame.EncodeInt32(left, right, w.buffers.mp3))
+	})
+}
+
+func (w *Writer) WritePlanarFloat32(left, right []float32) error {
+	frames, err := w.planarFrames(len(left), len(right))
+	if err != nil {
+		return err
+	}
+	if len(right) == 0 { // mono
+		right = left
+	}
+	return w.writeSamples(frames, func() ([]byte, error) {
+		if w.mixed() {
+			samples, l, r := w.floatBuffers(frames * w.InNumChannels)
+			interleaveFloat32(left, right, samples)
+			return w.encodeMixed(samples, l, r)
+		}
+		return w.mp3Result(w.lame.EncodeFloat32(left, right, w.buffers.mp3))
+	})
+}
+
+// count of frames of count interleaved samples
+func (w *Writer) interleavedFrames(count int) (int, error) {
+	if w.InNumChannels < 1 {
+		return 0, ErrUnsupportedChannelNum
+	}
+	if count%w.InNumChannels != 0 {
+		return 0, ErrIncompleteFrame
+	}
+	return count / w.InNumChannels, nil
+}
+
+// count of frames of planar channels of the given lengths, rightCount is 0 if mono
+func (w *Writer) planarFrames(leftCount, rightCount int) (int, error) {
+	switch {
+	case w.InNumChannels != 1 && w.InNumChannels != 2:
+		return 0, ErrPlanarChannels
+	case w.InNumChannels == 1 && rightCount != 0,
+		w.InNumChannels == 2 && rightCount != leftCount:
+		return 0, ErrChannelLengthMismatch
+	}
+	return leftCount, nil
+}
+
+// encode frames of samples by encode, which returns the encoded data
+// params are initialized first, and the mp3 buffer is grown for the frames
+func (w *Writer) writeSamples(frames int, encode func() ([]byte, error)) error {
+	if err := w.contextError(); err != nil {
+		return err
+	}
+	if len(w.pending) > 0 {
+		return ErrPendingBytes
+	}
+	if !w.lame.paramUpdated {
+		if err := w.ForceUpdateParams(); err != nil {
+			return err
+		}
+	}
+	if frames == 0 {
+		return nil
+	}
+	w.growMp3Buf(frames)
+	mp3Data, err := encode()
+	if err != nil {
+		return err
+	}
+	err = w.writeOutput(mp3Data)
+	w.samplesConsumed += int64(frames)
+	return err
+}
+
+// true if channels have to be mixed or resampled before encoding
+func (w *Writer) mixed() bool {
+	return w.mixer != nil || w.resamplers != nil
+}
+
+// buffers of count interleaved float samples, followed by the planar channels of them
+func (w *Writer) floatBuffers(count int) (samples, left, right []float32) {
+	var frames = count / w.InNumChannels
+	w.buffers.float32s = growFloat32(w.buffers.float32s, count+frames*2)
+	return w.buffers.float32s[:count], w.buffers.float32s[count : count+frames], w.buffers.float32s[count+frames:]
+}
+
+// the encoded data of n bytes in the mp3 buffer
+func (w *Writer) mp3Result(n int, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	return w.buffers.mp3[:n], nil
+}
+
+func int16ToFloat32(samples []int16, out []float32) {
+	for i, v := range samples {
+		out[i] = float32(v) / (1 << 15)
+	}
+}
+
+func int32ToFloat32(samples []int32, out []float32) {
+	for i, v := range samples {
+		out[i] = float32(v) / (1 << 31)
+	}
+}
+
+// interleave left and right into samples, or copy left if mono, i.e., left and right are the same
+func interleaveFloat32(left, right, samples []float32) {
+	if len(samples) == len(left) {
+		copy(samples, left)
+		return
+	}
+	for i := range left {
+		samples[i*2] = left[i]
+		samples[i*2+1] = right[i]
+	}
+}
@@ -0,0 +1,199 @@
+package lame
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"testing"
+)
+
+func readInt16Samples(t *testing.T) []int16 {
+	data, err := ioutil.ReadFile("res/1chan_s16ple.raw")
+	if err != nil {
+		t.Errorf("cannot read file, %s", err.Error())
+		return nil
+	}
+	samples := make([]int16, len(data)/2)
+	for i := range samples {
+		samples[i] = int16(binary.LittleEndian.Uint16(data[i*2:]))
+	}
+	return samples
+}
+
+// typed samples are encoded as the same bytes through Write
+func Test_SampleWriter_MatchesWrite(t *testing.T) {
+	samples := readInt16Samples(t)
+	if samples == nil {
+		return
+	}
+	stereo := make([]int16, len(samples)*2)
+	for i, v := range samples {
+		stereo[i*2], stereo[i*2+1] = v, v/2
+	}
+	tests := []struct {
+		name     string
+		channels int
+		rate     int // InSampleRate, resampled if LAME does not support it
+		write    func(SampleWriter, []int16) error
+	}{
+		{"int16", 1, 16000, func(w SampleWriter, s []int16) error { return w.WriteInt16(s) }},
+		{"planar int16", 1, 16000, func(w SampleWriter, s []int16) error { return w.WritePlanarInt16(s, nil) }},
+		{"int16 stereo", 2, 16000, func(w SampleWriter, s []int16) error { return w.WriteInt16(s) }},
+		{"int32 stereo", 2, 16000, func(w SampleWriter, s []int16) error { return w.WriteInt32(widenInt16(s)) }},
+		{"planar int32 stereo", 2, 16000, func(w SampleWriter, s []int16) error {
+			wide := widenInt16(s)
+			left, right := make([]int32, len(wide)/2), make([]int32, len(wide)/2)
+			deinterleaveInt32(wide, left, right)
+			return w.WritePlanarInt32(left, right)
+		}},
+		{"planar int16 resampled", 2, 96000, func(w SampleWriter, s []int16) error {
+			left, right := splitInt16(s)
+			return w.WritePlanarInt16(left, right)
+		}},
+	}
+	for _, test := range tests {
+		var input = samples
+		if test.channels == 2 {
+			input = stereo
+		}
+		var pcm = make([]byte, len(input)*2)
+		for i, v := range input {
+			binary.LittleEndian.PutUint16(pcm[i*2:], uint16(v))
+		}
+		encode := func(write func(*Writer) error) ([]byte, int64) {
+			out := new(bytes.Buffer)
+			wr, _ := NewWriter(out)
+			wr.InSampleRate = test.rate
+			wr.InNumChannels = test.channels
+			wr.OutSampleRate = 16000
+			if err := write(wr); err != nil {
+				t.Errorf("%s, cannot write, %s", test.name, err.Error())
+			}
+			var consumed = wr.samplesConsumed
+			wr.Close()
+			return out.Bytes(), consumed
+		}
+		expected, _ := encode(func(wr *Writer) error {
+			_, err := wr.Write(pcm)
+			return err
+		})
+		actual, consumed := encode(func(wr *Writer) error {
+			return test.write(wr, input)
+		})
+		if consumed != int64(len(samples)) {
+			t.Errorf("%s, expected %d samples consumed, got %d", test.name, len(samples), consumed)
+		}
+		if !bytes.Equal(expected, actual) {
+			t.Errorf("%s, expected the same mp3 as Write, got %d and %d bytes", test.name, len(expected), len(actual))
+		}
+	}
+}
+
+func Test_SampleWriter_Float32(t *testing.T) {
+	samples := readInt16Samples(t)
+	if samples == nil {
+		return
+	}
+	interleaved := make([]float32, len(samples)*2)
+	left, right := make([]float32, len(samples)), make([]float32, len(samples))
+	for i, v := range samples {
+		left[i], right[i] = float32(v)/(1<<15), float32(v)/(1<<16)
+		interleaved[i*2], interleaved[i*2+1] = left[i], right[i]
+	}
+	encode := func(write func(*Writer) error) []byte {
+		out := new(bytes.Buffer)
+		wr, _ := NewWriter(out)
+		wr.InSampleRate = 16000
+		wr.OutSampleRate = 16000
+		if err := write(wr); err != nil {
+			t.Errorf("cannot write, %s", err.Error())
+		}
+		wr.Close()
+		return out.Bytes()
+	}
+	expected := encode(func(wr *Writer) error {
+		return wr.WriteFloat32(interleaved)
+	})
+	actual := encode(func(wr *Writer) error {
+		return wr.WritePlanarFloat32(left, right)
+	})
+	if !bytes.Equal(expected, actual) {
+		t.Errorf("expected the same mp3 of interleaved and planar samples, got %d and %d bytes", len(expected), len(actual))
+	}
+}
+
+func Test_SampleWriter_Errors(t *testing.T) {
+	wr, err := NewWriter(ioutil.Discard)
+	if err != nil {
+		t.Errorf("cannot create lame writer, %s", err.Error())
+		return
+	}
+	defer wr.Close()
+	wr.InSampleRate = 16000
+	wr.OutSampleRate = 16000
+	tests := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{"incomplete frame", wr.WriteInt16(make([]int16, 3)), ErrIncompleteFrame},
+		{"different lengths", wr.WritePlanarFloat32(make([]float32, 4), make([]float32, 3)), ErrChannelLengthMismatch},
+		{"missing right", wr.WritePlanarInt16(make([]int16, 4), nil), ErrChannelLengthMismatch},
+		{"empty", wr.WriteFloat32(nil), nil},
+	}
+	for _, test := range tests {
+		if test.err != test.expected {
+			t.Errorf("%s, expected %v, got %v", test.name, test.expected, test.err)
+		}
+	}
+
+	wr.InNumChannels = 1
+	if err := wr.WritePlanarInt32(make([]int32, 4), make([]int32, 4)); err != ErrChannelLengthMismatch {
+		t.Errorf("mono with right, expected ErrChannelLengthMismatch, got %v", err)
+	}
+	wr.InNumChannels = 6
+	if err := wr.WritePlanarInt16(make([]int16, 4), make([]int16, 4)); err != ErrPlanarChannels {
+		t.Errorf("6 channels, expected ErrPlanarChannels, got %v", err)
+	}
+	if err := wr.WriteInt16(make([]int16, 8)); err != ErrIncompleteFrame {
+		t.Errorf("6 channels, expected ErrIncompleteFrame, got %v", err)
+	}
+}
+
+func Test_SampleWriter_PendingBytes(t *testing.T) {
+	wr, err := NewWriter(ioutil.Discard)
+	if err != nil {
+		t.Errorf("cannot create lame writer, %s", err.Error())
+		return
+	}
+	defer wr.Close()
+	if _, err = wr.Write(make([]byte, 6)); err != nil {
+		t.Errorf("cannot write, %s", err.Error())
+	}
+	if err = wr.WriteInt16(make([]int16, 2)); err != ErrPendingBytes {
+		t.Errorf("expected ErrPendingBytes, got %v", err)
+	}
+	if _, err = wr.Write(make([]byte, 2)); err != nil {
+		t.Errorf("cannot write, %s", err.Error())
+	}
+	if err = wr.WriteInt16(make([]int16, 2)); err != nil {
+		t.Errorf("expected samples written once the frame is complete, got %v", err)
+	}
+}
+
+func widenInt16(samples []int16) []int32 {
+	wide := make([]int32, len(samples))
+	for i, v := range samples {
+		wide[i] = int32(v) << 16
+	}
+	return wide
+}
+
+// split interleaved stereo samples into left and right ones
+func splitInt16(samples []int16) (left, right []int16) {
+	left, right = make([]int16, len(samples)/2), make([]int16, len(samples)/2)
+	for i := range left {
+		left[i], right[i] = samples[i*2], samples[i*2+1]
+	}
+	return left, right
+}
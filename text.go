@@ -0,0 +1,238 @@
+package lame
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Names of the enums, so that they read well in JSON/YAML configs, e.g., "joint_stereo" rather than 1
+// All of them implement fmt.Stringer, encoding.TextMarshaler and encoding.TextUnmarshaler. Names are case-insensitive,
+// and the value is left unchanged if the name is unknown
+
+var ErrInvalidName = errors.New("invalid name")
+
+// names indexed by the values of each enum
+var (
+	modeNames             = []string{"stereo", "joint_stereo", "dual_channel", "mono", "not_set"}
+	vbrModeNames          = []string{"vbr_off", "vbr_mt", "vbr_rh", "vbr_abr", "vbr_mtrh"}
+	asmOptimizationsNames = []string{"invalid", "mmx", "amd_3dnow", "sse"}
+	bitrateModeNames      = []string{"default", "cbr", "abr", "vbr"}
+	resampleQualityNames  = []string{"default", "low", "medium", "high"}
+	id3VersionNames       = []string{"auto", "v1_only", "v2_only", "v1_and_v2"}
+	sampleFormatNames     = []string{"int", "float"}
+	presetNames           = map[Preset]string{
+		PRESET_NONE:          "none",
+		PRESET_V9:            "v9",
+		PRESET_V8:            "v8",
+		PRESET_V7:            "v7",
+		PRESET_V6:            "v6",
+		PRESET_V5:            "v5",
+		PRESET_V4:            "v4",
+		PRESET_V3:            "v3",
+		PRESET_V2:            "v2",
+		PRESET_V1:            "v1",
+		PRESET_V0:            "v0",
+		PRESET_R3MIX:         "r3mix",
+		PRESET_STANDARD:      "standard",
+		PRESET_EXTREME:       "extreme",
+		PRESET_INSANE:        "insane",
+		PRESET_STANDARD_FAST: "standard_fast",
+		PRESET_EXTREME_FAST:  "extreme_fast",
+		PRESET_MEDIUM:        "medium",
+		PRESET_MEDIUM_FAST:   "medium_fast",
+	}
+)
+
+const _ABR_PRESET_PREFIX = "abr_" // e.g., "abr_128"
+
+// the name of value, false if it has none
+func nameOf(names []string, value int) (string, bool) {
+	if value < 0 || value >= len(names) {
+		return "", false
+	}
+	return names[value], true
+}
+
+func stringOf(kind string, names []string, value int) string {
+	if name, ok := nameOf(names, value); ok {
+		return name
+	}
+	return fmt.Sprintf("%s(%d)", kind, value)
+}
+
+func marshalName(kind string, names []string, value int) ([]byte, error) {
+	if name, ok := nameOf(names, value); ok {
+		return []byte(name), nil
+	}
+	return nil, fmt.Errorf("%w, no %s of %d", ErrInvalidName, kind, value)
+}
+
+// the value named by text
+func parseName(kind string, names []string, text []byte) (int, error) {
+	var name = strings.ToLower(strings.TrimSpace(string(text)))
+	for value, candidate := range names {
+		if candidate == name {
+			return value, nil
+		}
+	}
+	return 0, fmt.Errorf("%w, unknown %s %q, expected one of %s", ErrInvalidName, kind, text, strings.Join(names, ", "))
+}
+
+func (m Mode) String() string {
+	return stringOf("Mode", modeNames, int(m))
+}
+
+func (m Mode) MarshalText() ([]byte, error) {
+	return marshalName("Mode", modeNames, int(m))
+}
+
+func (m *Mode) UnmarshalText(text []byte) error {
+	value, err := parseName("Mode", modeNames, text)
+	if err != nil {
+		return err
+	}
+	*m = Mode(value)
+	return nil
+}
+
+// VBR_DEFAULT is named as VBR_MTRH, i.e., "vbr_mtrh"
+func (v VBRMode) String() string {
+	return stringOf("VBRMode", vbrModeNames, int(v))
+}
+
+func (v VBRMode) MarshalText() ([]byte, error) {
+	return marshalName("VBRMode", vbrModeNames, int(v))
+}
+
+// "vbr_default" is taken as well
+func (v *VBRMode) UnmarshalText(text []byte) error {
+	if strings.EqualFold(strings.TrimSpace(string(text)), "vbr_default") {
+		*v = VBR_DEFAULT
+		return nil
+	}
+	value, err := parseName("VBRMode", vbrModeNames, text)
+	if err != nil {
+		return err
+	}
+	*v = VBRMode(value)
+	return nil
+}
+
+func (a AsmOptimizations) String() string {
+	return stringOf("AsmOptimizations", asmOptimizationsNames, int(a))
+}
+
+func (a AsmOptimizations) MarshalText() ([]byte, error) {
+	return marshalName("AsmOptimizations", asmOptimizationsNames, int(a))
+}
+
+func (a *AsmOptimizations) UnmarshalText(text []byte) error {
+	value, err := parseName("AsmOptimizations", asmOptimizationsNames, text)
+	if err != nil {
+		return err
+	}
+	*a = AsmOptimizations(value)
+	return nil
+}
+
+func (b BitrateMode) String() string {
+	return stringOf("BitrateMode", bitrateModeNames, int(b))
+}
+
+func (b BitrateMode) MarshalText() ([]byte, error) {
+	return marshalName("BitrateMode", bitrateModeNames, int(b))
+}
+
+func (b *BitrateMode) UnmarshalText(text []byte) error {
+	value, err := parseName("BitrateMode", bitrateModeNames, text)
+	if err != nil {
+		return err
+	}
+	*b = BitrateMode(value)
+	return nil
+}
+
+func (q ResampleQuality) String() string {
+	return stringOf("ResampleQuality", resampleQualityNames, int(q))
+}
+
+func (q ResampleQuality) MarshalText() ([]byte, error) {
+	return marshalName("ResampleQuality", resampleQualityNames, int(q))
+}
+
+func (q *ResampleQuality) UnmarshalText(text []byte) error {
+	value, err := parseName("ResampleQuality", resampleQualityNames, text)
+	if err != nil {
+		return err
+	}
+	*q = ResampleQuality(value)
+	return nil
+}
+
+func (v Id3Version) String() string {
+	return stringOf("Id3Version", id3VersionNames, int(v))
+}
+
+func (v Id3Version) MarshalText() ([]byte, error) {
+	return marshalName("Id3Version", id3VersionNames, int(v))
+}
+
+func (v *Id3Version) UnmarshalText(text []byte) error {
+	value, err := parseName("Id3Version", id3VersionNames, text)
+	if err != nil {
+		return err
+	}
+	*v = Id3Version(value)
+	return nil
+}
+
+func (f SampleFormat) MarshalText() ([]byte, error) {
+	return marshalName("SampleFormat", sampleFormatNames, int(f))
+}
+
+func (f *SampleFormat) UnmarshalText(text []byte) error {
+	value, err := parseName("SampleFormat", sampleFormatNames, text)
+	if err != nil {
+		return err
+	}
+	*f = SampleFormat(value)
+	return nil
+}
+
+// e.g., "v2", "extreme", or "abr_128" for ABR presets
+func (p Preset) String() string {
+	if text, err := p.MarshalText(); err == nil {
+		return string(text)
+	}
+	return fmt.Sprintf("Preset(%d)", int(p))
+}
+
+func (p Preset) MarshalText() ([]byte, error) {
+	if name, ok := presetNames[p]; ok {
+		return []byte(name), nil
+	}
+	if p.valid() {
+		return []byte(_ABR_PRESET_PREFIX + strconv.Itoa(int(p))), nil
+	}
+	return nil, fmt.Errorf("%w, no Preset of %d", ErrInvalidName, int(p))
+}
+
+func (p *Preset) UnmarshalText(text []byte) error {
+	var name = strings.ToLower(strings.TrimSpace(string(text)))
+	for value, candidate := range presetNames {
+		if candidate == name {
+			*p = value
+			return nil
+		}
+	}
+	if strings.HasPrefix(name, _ABR_PRESET_PREFIX) {
+		kbps, err := strconv.Atoi(name[len(_ABR_PRESET_PREFIX):])
+		if err == nil && PresetABR(kbps).valid() {
+			*p = PresetABR(kbps)
+			return nil
+		}
+	}
+	return fmt.Errorf("%w, unknown Preset %q, expected v0-v9, abr_8-abr_320 or a named one", ErrInvalidName, text)
+}
@@ -0,0 +1,85 @@
+package lame
+
+import (
+	"encoding"
+	"errors"
+	"testing"
+)
+
+func Test_Text_RoundTrip(t *testing.T) {
+	tests := []struct {
+		value    encoding.TextMarshaler
+		decoded  encoding.TextUnmarshaler
+		expected string
+	}{
+		{MODE_JOINT_STEREO, new(Mode), "joint_stereo"},
+		{MODE_MONO, new(Mode), "mono"},
+		{VBR_DEFAULT, new(VBRMode), "vbr_mtrh"},
+		{VBR_ABR, new(VBRMode), "vbr_abr"},
+		{AO_AMD_3DNOW, new(AsmOptimizations), "amd_3dnow"},
+		{BITRATE_MODE_VBR, new(BitrateMode), "vbr"},
+		{RESAMPLE_QUALITY_HIGH, new(ResampleQuality), "high"},
+		{ID3_V1_AND_V2, new(Id3Version), "v1_and_v2"},
+		{SAMPLE_FORMAT_FLOAT, new(SampleFormat), "float"},
+		{PRESET_V2, new(Preset), "v2"},
+		{PRESET_EXTREME_FAST, new(Preset), "extreme_fast"},
+		{PresetABR(128), new(Preset), "abr_128"},
+	}
+	for _, test := range tests {
+		text, err := test.value.MarshalText()
+		if err != nil || string(text) != test.expected {
+			t.Errorf("%v, expected %q, got %q (%v)", test.value, test.expected, text, err)
+			continue
+		}
+		if err = test.decoded.UnmarshalText(text); err != nil {
+			t.Errorf("%s, cannot unmarshal, %s", text, err.Error())
+			continue
+		}
+		if again, _ := test.decoded.(encoding.TextMarshaler).MarshalText(); string(again) != test.expected {
+			t.Errorf("%s, expected to round trip, got %q", test.expected, again)
+		}
+	}
+}
+
+func Test_Text_Unmarshal(t *testing.T) {
+	var mode Mode
+	if err := mode.UnmarshalText([]byte(" Joint_Stereo ")); err != nil || mode != MODE_JOINT_STEREO {
+		t.Errorf("expected names case-insensitive, got %v (%v)", mode, err)
+	}
+	var vbr VBRMode
+	if err := vbr.UnmarshalText([]byte("vbr_default")); err != nil || vbr != VBR_DEFAULT {
+		t.Errorf("expected vbr_default taken, got %v (%v)", vbr, err)
+	}
+	mode, vbr = MODE_MONO, VBR_ABR
+	asm, bitrateMode, quality := AO_SSE, BITRATE_MODE_CBR, RESAMPLE_QUALITY_HIGH
+	id3, format, preset := ID3_V2_ONLY, SAMPLE_FORMAT_FLOAT, PRESET_V2
+	invalid := []struct {
+		decoded encoding.TextUnmarshaler
+		text    string
+		kept    string // the name of the value, which is left unchanged
+	}{
+		{&mode, "quadrophonic", "mono"},
+		{&vbr, "", "vbr_abr"},
+		{&asm, "avx", "sse"},
+		{&bitrateMode, "crf", "cbr"},
+		{&quality, "best", "high"},
+		{&id3, "v3", "v2_only"},
+		{&format, "double", "float"},
+		{&preset, "abr_400", "v2"},
+		{&preset, "v10", "v2"},
+	}
+	for _, test := range invalid {
+		if err := test.decoded.UnmarshalText([]byte(test.text)); !errors.Is(err, ErrInvalidName) {
+			t.Errorf("%q, expected ErrInvalidName, got %v", test.text, err)
+		}
+		if text, _ := test.decoded.(encoding.TextMarshaler).MarshalText(); string(text) != test.kept {
+			t.Errorf("%q, expected %s kept, got %s", test.text, test.kept, text)
+		}
+	}
+	if _, err := Mode(42).MarshalText(); !errors.Is(err, ErrInvalidName) {
+		t.Errorf("expected ErrInvalidName, got %v", err)
+	}
+	if s := Mode(42).String(); s != "Mode(42)" {
+		t.Errorf("expected Mode(42), got %s", s)
+	}
+}
@@ -0,0 +1,161 @@
+package lame
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Validation of EncodeOptions against the constraints of LAME, so that invalid options are reported field by field
+// before encoding starts, rather than as ErrCannotInitParams
+
+type (
+	// an invalid field of EncodeOptions
+	FieldError struct {
+		Field string // e.g., "OutQuality", or "Bitrate.Kbps"
+		Err   error
+	}
+
+	// all the invalid fields of EncodeOptions
+	ValidationError []*FieldError
+
+	// a bitrate the MPEG version of OutSampleRate does not support, errors.Is(err, ErrUnsupportedBitrate) holds
+	UnsupportedBitrateError struct {
+		Kbps       int   // the unsupported one
+		SampleRate int   // OutSampleRate
+		Allowed    []int // legal bitrates of layer III frames at SampleRate
+		InRange    bool  // true if any bitrate between the lowest and the highest allowed one is accepted, e.g., ABR and VBR bounds
+	}
+)
+
+var (
+	ErrInvalidQuality     = errors.New("invalid quality, expected 0 (highest) to 9 (lowest)")
+	ErrUnsupportedMode    = errors.New("unsupported mode, expected MODE_STEREO, MODE_JOINT_STEREO or MODE_MONO")
+	ErrInvalidInputRate   = errors.New("invalid input sample rate, expected a positive one")
+	ErrUnsupportedBitrate = errors.New("unsupported bitrate for the MPEG version of OutSampleRate")
+)
+
+// legal bitrates (kbps) of layer III frames
+var (
+	mpeg1Bitrates = []int{32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320} // 32k, 44.1k and 48k
+	mpeg2Bitrates = []int{8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160}     // 16k, 22.05k and 24k, as well as MPEG-2.5
+)
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+func (e UnsupportedBitrateError) Error() string {
+	if e.InRange {
+		return fmt.Sprintf("unsupported bitrate %dkbps at %dHz, expected %d-%dkbps",
+			e.Kbps, e.SampleRate, e.Allowed[0], e.Allowed[len(e.Allowed)-1])
+	}
+	return fmt.Sprintf("unsupported bitrate %dkbps at %dHz, expected one of %v kbps", e.Kbps, e.SampleRate, e.Allowed)
+}
+
+func (e UnsupportedBitrateError) Is(target error) bool {
+	return target == ErrUnsupportedBitrate
+}
+
+func (e ValidationError) Error() string {
+	var messages = make([]string, len(e))
+	for i, fieldErr := range e {
+		messages[i] = fieldErr.Error()
+	}
+	return "invalid encode options, " + strings.Join(messages, "; ")
+}
+
+// so that errors.Is and errors.As look into every field
+func (e ValidationError) Unwrap() []error {
+	var errs = make([]error, len(e))
+	for i, fieldErr := range e {
+		errs[i] = fieldErr
+	}
+	return errs
+}
+
+/*
+check every field, returns ValidationError naming each invalid one, or nil if all valid
+InSampleRate is not limited to the rates LAME supports, as others are resampled, while OutSampleRate is
+NOTE: a stereo OutMode with mono input is valid, as LAME encodes it in mono anyway
+*/
+func (opts *EncodeOptions) Validate() error {
+	var errs ValidationError
+	check := func(field string, err error) {
+		if err != nil {
+			errs = append(errs, &FieldError{Field: field, Err: err})
+		}
+	}
+	if opts.InSampleRate <= 0 {
+		check("InSampleRate", ErrInvalidInputRate)
+	}
+	if _, err := bytesPerSample(opts.InSampleFormat, opts.InBitsPerSample); err != nil {
+		check("InBitsPerSample", err)
+	}
+	if opts.InNumChannels < 1 {
+		check("InNumChannels", ErrUnsupportedChannelNum)
+	} else if opts.ChannelMatrix != nil {
+		check("ChannelMatrix", opts.ChannelMatrix.validate(opts.InNumChannels, opts.outNumChannels()))
+	} else if opts.InSelectChannel < 0 || opts.InSelectChannel > opts.InNumChannels {
+		check("InSelectChannel", ErrInvalidChannelSelect)
+	}
+	if NearestSampleRate(opts.OutSampleRate) != opts.OutSampleRate {
+		check("OutSampleRate", ErrInvalidSampleRate)
+	}
+	switch opts.OutMode {
+	case MODE_STEREO, MODE_JOINT_STEREO, MODE_MONO:
+	default:
+		check("OutMode", ErrUnsupportedMode)
+	}
+	if opts.OutQuality < 0 || opts.OutQuality > 9 {
+		check("OutQuality", ErrInvalidQuality)
+	}
+	if field, err := opts.Bitrate.validate(); err != nil {
+		check("Bitrate."+field, err)
+	} else if NearestSampleRate(opts.OutSampleRate) == opts.OutSampleRate {
+		opts.validateBitrates(check)
+	}
+	opts.validateOptional(check)
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// check the bitrates against the MPEG version of OutSampleRate
+// CBR must be one of the legal bitrates, while the others have to be in their range
+func (opts *EncodeOptions) validateBitrates(check func(string, error)) {
+	var legal = mpeg2Bitrates
+	if opts.OutSampleRate >= 32000 {
+		legal = mpeg1Bitrates
+	}
+	var inRange = func(kbps int) bool {
+		return kbps >= legal[0] && kbps <= legal[len(legal)-1]
+	}
+	var unsupported = func(kbps int, inRange bool) error {
+		return UnsupportedBitrateError{Kbps: kbps, SampleRate: opts.OutSampleRate, Allowed: legal, InRange: inRange}
+	}
+	var b = &opts.Bitrate
+	switch {
+	case b.Mode == BITRATE_MODE_CBR:
+		var found = false
+		for _, kbps := range legal {
+			found = found || kbps == b.Kbps
+		}
+		if !found {
+			check("Bitrate.Kbps", unsupported(b.Kbps, false))
+		}
+	case b.Mode == BITRATE_MODE_ABR && !inRange(b.Kbps):
+		check("Bitrate.Kbps", unsupported(b.Kbps, true))
+	}
+	if b.MinKbps != 0 && !inRange(b.MinKbps) {
+		check("Bitrate.MinKbps", unsupported(b.MinKbps, true))
+	}
+	if b.MaxKbps != 0 && !inRange(b.MaxKbps) {
+		check("Bitrate.MaxKbps", unsupported(b.MaxKbps, true))
+	}
+}
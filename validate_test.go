@@ -0,0 +1,132 @@
+package lame
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func Test_EncodeOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     func(opts *EncodeOptions)
+		expected map[string]error // field -> error
+	}{
+		{"valid", func(opts *EncodeOptions) {}, nil},
+		{"valid cbr", func(opts *EncodeOptions) {
+			opts.Bitrate = BitrateOptions{Mode: BITRATE_MODE_CBR, Kbps: 144}
+		}, nil},
+		{"unsupported input rate resampled", func(opts *EncodeOptions) { opts.InSampleRate = 96000 }, nil},
+		{"cbr 320 at 11025", func(opts *EncodeOptions) {
+			opts.OutSampleRate = 11025
+			opts.Bitrate = BitrateOptions{Mode: BITRATE_MODE_CBR, Kbps: 320}
+		}, map[string]error{"Bitrate.Kbps": ErrUnsupportedBitrate}},
+		{"cbr 144 at 44100", func(opts *EncodeOptions) {
+			opts.OutSampleRate = 44100
+			opts.Bitrate = BitrateOptions{Mode: BITRATE_MODE_CBR, Kbps: 144}
+		}, map[string]error{"Bitrate.Kbps": ErrUnsupportedBitrate}},
+		{"vbr bounds at 48000", func(opts *EncodeOptions) {
+			opts.OutSampleRate = 48000
+			opts.Bitrate = BitrateOptions{Mode: BITRATE_MODE_VBR, MinKbps: 8, MaxKbps: 320}
+		}, map[string]error{"Bitrate.MinKbps": ErrUnsupportedBitrate}},
+		{"bitrate conflict", func(opts *EncodeOptions) {
+			opts.Bitrate = BitrateOptions{Mode: BITRATE_MODE_VBR, Kbps: 128}
+		}, map[string]error{"Bitrate.Kbps": ErrBitrateConflict}},
+		{"invalid max", func(opts *EncodeOptions) {
+			opts.Bitrate = BitrateOptions{Mode: BITRATE_MODE_VBR, MaxKbps: 400}
+		}, map[string]error{"Bitrate.MaxKbps": ErrInvalidBitrate}},
+		{"many", func(opts *EncodeOptions) {
+			opts.OutQuality = 12
+			opts.OutMode = MODE_DUAL_CHANNEL
+			opts.OutSampleRate = 96000
+			opts.InBitsPerSample = 12
+			opts.InSampleRate = 0
+		}, map[string]error{
+			"OutQuality":      ErrInvalidQuality,
+			"OutMode":         ErrUnsupportedMode,
+			"OutSampleRate":   ErrInvalidSampleRate,
+			"InBitsPerSample": UnsupportedBitsPerSampleError{Format: SAMPLE_FORMAT_INT, BitsPerSample: 12},
+			"InSampleRate":    ErrInvalidInputRate,
+		}},
+		{"channels", func(opts *EncodeOptions) { opts.InNumChannels = 0 }, map[string]error{"InNumChannels": ErrUnsupportedChannelNum}},
+		{"select", func(opts *EncodeOptions) { opts.InSelectChannel = 2 }, map[string]error{"InSelectChannel": ErrInvalidChannelSelect}},
+		{"matrix", func(opts *EncodeOptions) {
+			opts.ChannelMatrix = &ChannelMatrix{{1}, {1}}
+		}, map[string]error{"ChannelMatrix": ErrInvalidChannelMatrix}},
+	}
+	for _, test := range tests {
+		opts := monoOptions()
+		test.opts(&opts)
+		err := opts.Validate()
+		if test.expected == nil {
+			if err != nil {
+				t.Errorf("%s, expected valid, got %s", test.name, err.Error())
+			}
+			continue
+		}
+		var validationErr ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("%s, expected ValidationError, got %v", test.name, err)
+			continue
+		}
+		if len(validationErr) != len(test.expected) {
+			t.Errorf("%s, expected %d invalid fields, got %s", test.name, len(test.expected), err.Error())
+		}
+		for _, fieldErr := range validationErr {
+			if expected, ok := test.expected[fieldErr.Field]; !ok || !errors.Is(err, expected) {
+				t.Errorf("%s, unexpected %s", test.name, fieldErr.Error())
+			}
+			if !strings.Contains(err.Error(), fieldErr.Field+": ") {
+				t.Errorf("%s, expected %s named in %q", test.name, fieldErr.Field, err.Error())
+			}
+		}
+	}
+}
+
+// the writer reports invalid options before lame is initialized
+func Test_Encoder_Validate(t *testing.T) {
+	wr, err := NewWriter(new(bytes.Buffer))
+	if err != nil {
+		t.Errorf("cannot create lame writer, %s", err.Error())
+		return
+	}
+	defer wr.Close()
+	wr.OutQuality = 12
+	if _, err = wr.Write(make([]byte, 4)); !errors.Is(err, ErrInvalidQuality) {
+		t.Errorf("expected ErrInvalidQuality, got %v", err)
+	}
+	if wr.lame.paramUpdated {
+		t.Errorf("expected params not initialized")
+	}
+}
+
+func Test_EncodeOptions_Validate_BitrateMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		rate     int
+		bitrate  BitrateOptions
+		expected string
+	}{
+		{"cbr", 11025, BitrateOptions{Mode: BITRATE_MODE_CBR, Kbps: 320},
+			"Bitrate.Kbps: unsupported bitrate 320kbps at 11025Hz, expected one of [8 16 24 32 40 48 56 64 80 96 112 128 144 160] kbps"},
+		{"vbr min", 48000, BitrateOptions{Mode: BITRATE_MODE_VBR, MinKbps: 8},
+			"Bitrate.MinKbps: unsupported bitrate 8kbps at 48000Hz, expected 32-320kbps"},
+		{"abr max", 22050, BitrateOptions{Mode: BITRATE_MODE_ABR, Kbps: 128, MaxKbps: 192},
+			"Bitrate.MaxKbps: unsupported bitrate 192kbps at 22050Hz, expected 8-160kbps"},
+	}
+	for _, test := range tests {
+		opts := monoOptions()
+		opts.OutSampleRate = test.rate
+		opts.Bitrate = test.bitrate
+		err := opts.Validate()
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("%s, expected %q, got %v", test.name, test.expected, err)
+			continue
+		}
+		var bitrateErr UnsupportedBitrateError
+		if !errors.As(err, &bitrateErr) || !errors.Is(err, ErrUnsupportedBitrate) {
+			t.Errorf("%s, expected UnsupportedBitrateError, got %#v", test.name, err)
+		}
+	}
+}
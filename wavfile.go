@@ -2,21 +2,45 @@ package lame
 
 import (
 	"io"
+	"io/ioutil"
+	"bytes"
 	"encoding/binary"
 	"errors"
+	"fmt"
 )
 
 // WAV file spec reference:
 // http://www.topherlee.com/software/pcm-tut-wavformat.html
 // properties mostly name after:
 // http://soundfile.sapp.org/doc/WaveFormat/
+// chunks are walked one by one, as described in:
+// http://www-mmsp.ece.mcgill.ca/Documents/AudioFormats/WAVE/WAVE.html
 
 type (
-	// The header of a common wav file, length=44B
+	// The header of a wav file
+	// for the canonical ones, length=44B, while real files might contain other chunks (LIST, fact, bext, JUNK, etc.)
 	WavHeader struct {
 		// RIFF Header
 		ChunkId   [4]byte // fixed "RIFF" or "RIFX" if the file is big-endian
 		WavHeaderRemaining
+
+		FormatExtension []byte         // bytes of "fmt " chunk beyond the first 16 ones, e.g., cbSize and the extension of WAVE_FORMAT_EXTENSIBLE
+		Extensible      *WavExtensible // parsed FormatExtension if AudioFormat is WAVE_FORMAT_EXTENSIBLE, otherwise nil
+		Chunks          []WavChunk     // chunks other than "fmt " and "data", in the order of appearance
+	}
+
+	// the extension of "fmt " chunk for WAVE_FORMAT_EXTENSIBLE
+	WavExtensible struct {
+		ValidBitsPerSample uint16   // bits of precision, e.g., 24 in 32bit containers
+		ChannelMask        uint32   // speaker positions of the channels, e.g., 0x3F for 5.1
+		SubFormat          [16]byte // GUID of the real format, as is in the file
+		subFormatTag       uint16   // AudioFormat the GUID stands for, 0 if it is not a KSDATAFORMAT_SUBTYPE_* one
+	}
+
+	// returned if the audio format cannot be converted
+	UnsupportedWavFormatError struct {
+		AudioFormat uint16 // the effective format, e.g., 2 for ADPCM, or the sub-format of WAVE_FORMAT_EXTENSIBLE
+		Extensible  bool
 	}
 
 	WavHeaderRemaining struct {
@@ -25,8 +49,8 @@ type (
 
 		// Format Header
 		SubChunk1Id   [4]byte // fixed "fmt\0"
-		SubChunk1Size int32   // Length of format data as listed above
-		AudioFormat   int16   // Type of format (1 is PCM) - 2 byte integer
+		SubChunk1Size int32   // Length of format data as listed above, 16, 18 or 40
+		AudioFormat   int16   // Type of format (1 is PCM, 3 is IEEE float, -2 i.e. 0xFFFE is extensible) - 2 byte integer, see FormatTag
 		NumChannels   int16   // Number of Channels - 2 byte integer
 		SampleRate    int32   // Sample Rate - 32 byte integer. Common values are 44100 (CD), 48000 (DAT). Sample Rate = Number of Samples per second, or Hertz.
 		ByteRate      int32   // *NOT BIT RATE*, but byte rate (Sample Rate * BitsPerSample * Channels) / 8.
@@ -37,6 +61,22 @@ type (
 		SubChunk2Id   [4]byte // Contains "data"
 		SubChunk2Size int32   // Number of bytes in data. Number of samples * num_channels * sample byte size
 	}
+
+	// a chunk which is neither "fmt " nor "data"
+	WavChunk struct {
+		Id   [4]byte // e.g., "LIST", "fact", "bext", "JUNK", "cue "
+		Data []byte  // payload, without the padding byte
+	}
+
+	// the first 16 bytes of "fmt " chunk
+	wavFormat struct {
+		AudioFormat   uint16
+		NumChannels   int16
+		SampleRate    int32
+		ByteRate      int32
+		BlockAlign    int16
+		BitsPerSample int16
+	}
 )
 
 var (
@@ -46,6 +86,28 @@ var (
 	ErrCannotReadChunkId = errors.New("cannot read chunkId")
 	// Cannot read header at all
 	ErrCannotReadHeader = errors.New("cannot read headers")
+	// Format is not "WAVE"
+	ErrInvalidWavFormat = errors.New("invalid wav format, expected WAVE")
+	// "fmt " chunk is too short
+	ErrInvalidFmtChunk = errors.New("invalid fmt chunk, expected at least 16 bytes")
+	// "data" chunk comes before "fmt " chunk, or "fmt " chunk not found at all
+	ErrMissingFmtChunk = errors.New("fmt chunk not found before data chunk")
+	// no "data" chunk found
+	ErrMissingDataChunk = errors.New("data chunk not found")
+	// the extension of WAVE_FORMAT_EXTENSIBLE is too short
+	ErrInvalidFormatExtensible = errors.New("invalid fmt chunk, expected 22 bytes of extension for WAVE_FORMAT_EXTENSIBLE")
+)
+
+// values of AudioFormat
+const (
+	WAVE_FORMAT_PCM        = 1
+	WAVE_FORMAT_IEEE_FLOAT = 3
+	WAVE_FORMAT_EXTENSIBLE = 0xFFFE
+)
+
+const (
+	_WAV_FORMAT_SIZE            = 16 // size of wavFormat
+	_WAV_FORMAT_EXTENSIBLE_SIZE = 24 // size of cbSize + the extension
 )
 
 var (
@@ -55,10 +117,16 @@ var (
 	format = [4]byte{'W', 'A', 'V', 'E'}
 	subChunk1Id = [4]byte{'f', 'm', 't', ' '}
 	subChunk2Id = [4]byte{'d', 'a', 't', 'a'}
+
+	// KSDATAFORMAT_SUBTYPE_* GUIDs are {XXXXXXXX-0000-0010-8000-00AA00389B71}, where XXXXXXXX is the AudioFormat
+	subFormatGuidTail = [8]byte{0x80, 0x00, 0x00, 0xAA, 0x00, 0x38, 0x9B, 0x71}
 )
 
 // Try to read the wav header from the given reader
+// all chunks before "data" are walked through, and the reader is positioned exactly at the start of the data payload
 // returns non-nil err if error occurs
+// returns UnsupportedWavFormatError along with the header if samples are neither integer PCM nor IEEE float,
+// in which case the reader is still positioned at the start of the data payload
 // NOTE: the reader's position would be permanently changed, even if the given data is corrupted
 func ReadWavHeader(reader io.Reader) (hdr *WavHeader, err error) {
 	hdr = new(WavHeader)
@@ -71,32 +139,179 @@ func ReadWavHeader(reader io.Reader) (hdr *WavHeader, err error) {
 		return
 	}
 
-	if hdr.ChunkId == chunkIdLe {
-		err = binary.Read(reader, binary.LittleEndian, &hdr.WavHeaderRemaining)
-	} else {
-		err = binary.Read(reader, binary.BigEndian, &hdr.WavHeaderRemaining)
+	var order binary.ByteOrder = binary.LittleEndian
+	if hdr.IsBigEndian() {
+		order = binary.BigEndian
 	}
-
-	if err != nil {
+	if err = binary.Read(reader, order, &hdr.ChunkSize); err != nil {
+		err = ErrCannotReadHeader
+		return
+	}
+	if err = binary.Read(reader, order, &hdr.Format); err != nil {
 		err = ErrCannotReadHeader
+		return
+	} else if hdr.Format != format {
+		err = ErrInvalidWavFormat
+		return
 	}
 
-	return
+	var fmtFound bool
+	for {
+		var id [4]byte
+		var size uint32
+		if err = binary.Read(reader, order, &id); err == io.EOF {
+			err = ErrMissingDataChunk
+			return
+		} else if err != nil {
+			err = ErrCannotReadHeader
+			return
+		}
+		if err = binary.Read(reader, order, &size); err != nil {
+			err = ErrCannotReadHeader
+			return
+		}
+
+		if id == subChunk2Id {
+			if !fmtFound {
+				err = ErrMissingFmtChunk
+				return
+			}
+			hdr.SubChunk2Id = id
+			hdr.SubChunk2Size = int32(size)
+			// right at the start of data
+			if formatTag := hdr.FormatTag(); formatTag != WAVE_FORMAT_PCM && formatTag != WAVE_FORMAT_IEEE_FLOAT {
+				err = UnsupportedWavFormatError{AudioFormat: formatTag, Extensible: hdr.Extensible != nil}
+			}
+			return
+		}
+
+		// NOTE: never trust the size, only bytes really read are kept
+		payload := new(bytes.Buffer)
+		if _, err = io.CopyN(payload, reader, int64(size)); err != nil {
+			err = ErrCannotReadHeader
+			return
+		}
+		// chunks are always word-aligned, the padding byte is not included in the size
+		if size%2 == 1 {
+			if _, err = io.CopyN(ioutil.Discard, reader, 1); err != nil && err != io.EOF {
+				err = ErrCannotReadHeader
+				return
+			}
+		}
+
+		if id == subChunk1Id {
+			if err = hdr.parseFormat(id, payload.Bytes(), order); err != nil {
+				return
+			}
+			fmtFound = true
+		} else {
+			hdr.Chunks = append(hdr.Chunks, WavChunk{Id: id, Data: payload.Bytes()})
+		}
+	}
+}
+
+// fill format fields by the payload of "fmt " chunk
+func (hdr *WavHeader) parseFormat(id [4]byte, payload []byte, order binary.ByteOrder) error {
+	if len(payload) < _WAV_FORMAT_SIZE {
+		return ErrInvalidFmtChunk
+	}
+	var f wavFormat
+	if err := binary.Read(bytes.NewReader(payload), order, &f); err != nil {
+		return ErrInvalidFmtChunk
+	}
+	hdr.SubChunk1Id = id
+	hdr.SubChunk1Size = int32(len(payload))
+	hdr.AudioFormat = int16(f.AudioFormat)
+	hdr.NumChannels = f.NumChannels
+	hdr.SampleRate = f.SampleRate
+	hdr.ByteRate = f.ByteRate
+	hdr.BlockAlign = f.BlockAlign
+	hdr.BitsPerSample = f.BitsPerSample
+	if len(payload) > _WAV_FORMAT_SIZE {
+		hdr.FormatExtension = payload[_WAV_FORMAT_SIZE:]
+	}
+	if f.AudioFormat == WAVE_FORMAT_EXTENSIBLE {
+		return hdr.parseExtensible(order)
+	}
+	return nil
+}
+
+// fill Extensible by FormatExtension, which is:
+// cbSize(2) + wValidBitsPerSample(2) + dwChannelMask(4) + SubFormat(16)
+func (hdr *WavHeader) parseExtensible(order binary.ByteOrder) error {
+	ext := hdr.FormatExtension
+	if len(ext) < _WAV_FORMAT_EXTENSIBLE_SIZE || order.Uint16(ext) < _WAV_FORMAT_EXTENSIBLE_SIZE-2 {
+		return ErrInvalidFormatExtensible
+	}
+	hdr.Extensible = &WavExtensible{
+		ValidBitsPerSample: order.Uint16(ext[2:]),
+		ChannelMask:        order.Uint32(ext[4:]),
+	}
+	copy(hdr.Extensible.SubFormat[:], ext[8:24])
+
+	// the GUID is {Data1(4), Data2(2), Data3(2), Data4(8)}, where the integers are in file's byte order
+	guid := hdr.Extensible.SubFormat[:]
+	var tail [8]byte
+	copy(tail[:], guid[8:])
+	if data1 := order.Uint32(guid); data1 <= 0xFFFF && order.Uint16(guid[4:]) == 0 && order.Uint16(guid[6:]) == 0x0010 && tail == subFormatGuidTail {
+		hdr.Extensible.subFormatTag = uint16(data1)
+	}
+	return nil
+}
+
+// the effective AudioFormat, i.e., the sub-format for WAVE_FORMAT_EXTENSIBLE
+func (hdr *WavHeader) FormatTag() uint16 {
+	var formatTag = uint16(hdr.AudioFormat)
+	if formatTag == WAVE_FORMAT_EXTENSIBLE && hdr.Extensible != nil {
+		return hdr.Extensible.subFormatTag
+	}
+	return formatTag
+}
+
+func (e UnsupportedWavFormatError) Error() string {
+	if e.Extensible {
+		return fmt.Sprintf("unsupported sub-format %#04x of WAVE_FORMAT_EXTENSIBLE, supports only PCM and IEEE float", e.AudioFormat)
+	}
+	return fmt.Sprintf("unsupported wav audio format %#04x, supports only PCM and IEEE float", e.AudioFormat)
 }
 
 func (hdr *WavHeader) IsBigEndian() bool {
 	return hdr.ChunkId == chunkIdBe
 }
 
+// find the first chunk of the given id, e.g., "LIST", nil if not found
+// NOTE: "fmt " and "data" are not kept as chunks
+func (hdr *WavHeader) Chunk(id string) *WavChunk {
+	for i := range hdr.Chunks {
+		if string(hdr.Chunks[i].Id[:]) == id {
+			return &hdr.Chunks[i]
+		}
+	}
+	return nil
+}
+
 // build an encodeOptions object by wavHeader
+// samples are regarded as IEEE float if the (sub-)format is WAVE_FORMAT_IEEE_FLOAT, otherwise integer
+// OutSampleRate is the nearest rate LAME supports, e.g., 48000 of a 96000 file, see NearestSampleRate
+// NOTE: InBitsPerSample is the container size, e.g., 32 for 24 valid bits, as samples are aligned to the most significant bit
 func (hdr *WavHeader) ToEncodeOptions() EncodeOptions {
+	sampleFormat := SAMPLE_FORMAT_INT
+	if hdr.FormatTag() == WAVE_FORMAT_IEEE_FLOAT {
+		sampleFormat = SAMPLE_FORMAT_FLOAT
+	}
+	var channelMask uint32
+	if hdr.Extensible != nil {
+		channelMask = hdr.Extensible.ChannelMask
+	}
 	return EncodeOptions{
 		InBigEndian:     hdr.IsBigEndian(),
 		InSampleRate:    int(hdr.SampleRate),
 		InBitsPerSample: int(hdr.BitsPerSample),
+		InSampleFormat:  sampleFormat,
 		InNumChannels:   int(hdr.NumChannels),
-		OutSampleRate:   int(hdr.SampleRate), // default: remains unchanged
+		InChannelMask:   channelMask,
+		OutSampleRate:   NearestSampleRate(int(hdr.SampleRate)), // default: unchanged if LAME supports it, otherwise resampled
 		OutMode:         MODE_STEREO,
 		OutQuality:      0,
 	}
-}
\ No newline at end of file
+}
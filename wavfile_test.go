@@ -3,7 +3,11 @@ package lame
 import (
 	"testing"
 	"os"
-	"./compare"
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+
+	"github.com/sunicy/go-lame/compare"
 )
 
 func Test_ReadWavHeader(t *testing.T) {
@@ -45,7 +49,7 @@ func Test_ReadWavHeader(t *testing.T) {
 				t.Errorf("Case#%d, %s", idx, err.Error())
 				return
 			}
-			diffs, err := compare.Compare(&test.hdr, &hdr)
+			diffs, err := compare.Compare(&test.hdr, hdr)
 			if err != nil {
 				t.Errorf("%s", err.Error())
 			} else if len(diffs) > 0 {
@@ -61,3 +65,229 @@ func Test_LameStruct(t *testing.T) {
 	t.Logf("%#v", err)
 }
 
+
+func Test_WavHeader_ToEncodeOptions_Float(t *testing.T) {
+	hdr := WavHeader{
+		ChunkId: chunkIdLe,
+		WavHeaderRemaining: WavHeaderRemaining{
+			AudioFormat:   WAVE_FORMAT_IEEE_FLOAT,
+			NumChannels:   2,
+			SampleRate:    48000,
+			BitsPerSample: 32,
+		},
+	}
+	opts := hdr.ToEncodeOptions()
+	if opts.InSampleFormat != SAMPLE_FORMAT_FLOAT || opts.InBitsPerSample != 32 || opts.InNumChannels != 2 {
+		t.Errorf("unexpected options %#v", opts)
+	}
+}
+
+func Test_WavHeader_ToEncodeOptions_Resample(t *testing.T) {
+	for _, rate := range []int{96000, 192000} {
+		samples := sineWave(rate, 440, rate/10)
+		pcm := new(bytes.Buffer)
+		for _, s := range samples {
+			binary.Write(pcm, binary.LittleEndian, int16(s*32767))
+			binary.Write(pcm, binary.LittleEndian, int16(s*32767))
+		}
+		f := wavFormat{AudioFormat: WAVE_FORMAT_PCM, NumChannels: 2, SampleRate: int32(rate), ByteRate: int32(rate * 4), BlockAlign: 4, BitsPerSample: 16}
+		le := binary.LittleEndian
+		reader := bytes.NewReader(buildWav(le, buildFmt(le, f, nil), WavChunk{Id: subChunk2Id, Data: pcm.Bytes()}))
+		hdr, err := ReadWavHeader(reader)
+		if err != nil {
+			t.Errorf("%d: cannot read header, %s", rate, err.Error())
+			continue
+		}
+		opts := hdr.ToEncodeOptions()
+		if opts.InSampleRate != rate || opts.OutSampleRate != 48000 {
+			t.Errorf("%d: expected %d->48000, got %d->%d", rate, rate, opts.InSampleRate, opts.OutSampleRate)
+			continue
+		}
+		wr, err := NewWriter(new(bytes.Buffer))
+		if err != nil {
+			t.Errorf("%d: cannot create lame writer, %s", rate, err.Error())
+			continue
+		}
+		wr.EncodeOptions = opts
+		if n, err := reader.WriteTo(wr); err != nil {
+			t.Errorf("%d: cannot encode, %s", rate, err.Error())
+		} else if n != int64(pcm.Len()) {
+			t.Errorf("%d: expected %d bytes encoded, got %d", rate, pcm.Len(), n)
+		}
+		if len(wr.resamplers) != 2 {
+			t.Errorf("%d: expected 2 resamplers, got %d", rate, len(wr.resamplers))
+		}
+		if err = wr.Close(); err != nil {
+			t.Errorf("%d: cannot close, %s", rate, err.Error())
+		}
+	}
+}
+
+// build a wav file chunk by chunk
+func buildWav(order binary.ByteOrder, chunks ...WavChunk) []byte {
+	body := new(bytes.Buffer)
+	body.WriteString("WAVE")
+	for _, chunk := range chunks {
+		body.Write(chunk.Id[:])
+		binary.Write(body, order, uint32(len(chunk.Data)))
+		body.Write(chunk.Data)
+		if len(chunk.Data)%2 == 1 {
+			body.WriteByte(0)
+		}
+	}
+	wav := new(bytes.Buffer)
+	if order == binary.ByteOrder(binary.BigEndian) {
+		wav.Write(chunkIdBe[:])
+	} else {
+		wav.Write(chunkIdLe[:])
+	}
+	binary.Write(wav, order, uint32(body.Len()))
+	wav.Write(body.Bytes())
+	return wav.Bytes()
+}
+
+func buildFmt(order binary.ByteOrder, f wavFormat, extension []byte) WavChunk {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, order, f)
+	buf.Write(extension)
+	return WavChunk{Id: subChunk1Id, Data: buf.Bytes()}
+}
+
+func Test_ReadWavHeader_Chunks(t *testing.T) {
+	pcm := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	f := wavFormat{AudioFormat: WAVE_FORMAT_PCM, NumChannels: 2, SampleRate: 48000, ByteRate: 48000 * 4, BlockAlign: 4, BitsPerSample: 16}
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		wav := buildWav(order,
+			WavChunk{Id: [4]byte{'J', 'U', 'N', 'K'}, Data: make([]byte, 28)},
+			buildFmt(order, f, []byte{0, 0}),                                   // 18 bytes, cbSize=0
+			WavChunk{Id: [4]byte{'L', 'I', 'S', 'T'}, Data: []byte("INFOodd")}, // odd size, padded
+			WavChunk{Id: [4]byte{'f', 'a', 'c', 't'}, Data: []byte{2, 0, 0, 0}},
+			WavChunk{Id: subChunk2Id, Data: pcm},
+		)
+		reader := bytes.NewReader(wav)
+		hdr, err := ReadWavHeader(reader)
+		if err != nil {
+			t.Errorf("%s: cannot read header, %s", order, err.Error())
+			continue
+		}
+		if hdr.IsBigEndian() != (order == binary.ByteOrder(binary.BigEndian)) {
+			t.Errorf("%s: unexpected endian", order)
+		}
+		if hdr.SubChunk1Size != 18 || hdr.NumChannels != 2 || hdr.SampleRate != 48000 || hdr.BitsPerSample != 16 {
+			t.Errorf("%s: unexpected format %#v", order, hdr.WavHeaderRemaining)
+		}
+		if !bytes.Equal(hdr.FormatExtension, []byte{0, 0}) {
+			t.Errorf("%s: unexpected format extension %#v", order, hdr.FormatExtension)
+		}
+		if len(hdr.Chunks) != 3 || hdr.Chunk("LIST") == nil || string(hdr.Chunk("LIST").Data) != "INFOodd" || hdr.Chunk("cue ") != nil {
+			t.Errorf("%s: unexpected chunks %#v", order, hdr.Chunks)
+		}
+		if hdr.SubChunk2Size != int32(len(pcm)) {
+			t.Errorf("%s: unexpected data size %d", order, hdr.SubChunk2Size)
+		}
+		if remaining, _ := ioutil.ReadAll(reader); !bytes.Equal(remaining, pcm) {
+			t.Errorf("%s: reader not positioned at data, remaining=%#v", order, remaining)
+		}
+	}
+}
+
+func Test_ReadWavHeader_Invalid(t *testing.T) {
+	f := wavFormat{AudioFormat: WAVE_FORMAT_PCM, NumChannels: 1, SampleRate: 8000, ByteRate: 8000, BlockAlign: 1, BitsPerSample: 8}
+	le := binary.LittleEndian
+	tests := []struct {
+		name     string
+		wav      []byte
+		expected error
+	}{
+		{"not riff", []byte("RIFA"), ErrInvalidWavChunkId},
+		{"not wave", append(buildWav(le)[:8], []byte("AVI ")...), ErrInvalidWavFormat},
+		{"no data", buildWav(le, buildFmt(le, f, nil)), ErrMissingDataChunk},
+		{"data before fmt", buildWav(le, WavChunk{Id: subChunk2Id, Data: []byte{0}}, buildFmt(le, f, nil)), ErrMissingFmtChunk},
+		{"short fmt", buildWav(le, WavChunk{Id: subChunk1Id, Data: make([]byte, 14)}, WavChunk{Id: subChunk2Id}), ErrInvalidFmtChunk},
+		{"truncated chunk", buildWav(le, buildFmt(le, f, nil))[:30], ErrCannotReadHeader},
+	}
+	for _, test := range tests {
+		if _, err := ReadWavHeader(bytes.NewReader(test.wav)); err != test.expected {
+			t.Errorf("%s: expected %#v, got %#v", test.name, test.expected, err)
+		}
+	}
+}
+
+func buildExtensible(order binary.ByteOrder, validBits uint16, channelMask uint32, subFormat uint16) []byte {
+	ext := make([]byte, 24)
+	order.PutUint16(ext, 22)
+	order.PutUint16(ext[2:], validBits)
+	order.PutUint32(ext[4:], channelMask)
+	order.PutUint32(ext[8:], uint32(subFormat))
+	order.PutUint16(ext[14:], 0x0010)
+	copy(ext[16:], subFormatGuidTail[:])
+	return ext
+}
+
+func Test_ReadWavHeader_Extensible(t *testing.T) {
+	f := wavFormat{AudioFormat: WAVE_FORMAT_EXTENSIBLE, NumChannels: 6, SampleRate: 48000, ByteRate: 48000 * 24, BlockAlign: 24, BitsPerSample: 32}
+	tests := []struct {
+		name         string
+		subFormat    uint16
+		sampleFormat SampleFormat
+	}{
+		{"pcm", WAVE_FORMAT_PCM, SAMPLE_FORMAT_INT},
+		{"float", WAVE_FORMAT_IEEE_FLOAT, SAMPLE_FORMAT_FLOAT},
+	}
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		for _, test := range tests {
+			wav := buildWav(order,
+				buildFmt(order, f, buildExtensible(order, 24, 0x3F, test.subFormat)),
+				WavChunk{Id: subChunk2Id, Data: make([]byte, 24)},
+			)
+			hdr, err := ReadWavHeader(bytes.NewReader(wav))
+			if err != nil {
+				t.Errorf("%s/%s: cannot read header, %s", order, test.name, err.Error())
+				continue
+			}
+			if hdr.Extensible == nil || hdr.Extensible.ValidBitsPerSample != 24 || hdr.Extensible.ChannelMask != 0x3F {
+				t.Errorf("%s/%s: unexpected extension %#v", order, test.name, hdr.Extensible)
+				continue
+			}
+			if hdr.FormatTag() != test.subFormat {
+				t.Errorf("%s/%s: unexpected format tag %#x", order, test.name, hdr.FormatTag())
+			}
+			if uint16(hdr.AudioFormat) != WAVE_FORMAT_EXTENSIBLE {
+				t.Errorf("%s/%s: expected AudioFormat 0xFFFE, got %d", order, test.name, hdr.AudioFormat)
+			}
+			opts := hdr.ToEncodeOptions()
+			if opts.InSampleFormat != test.sampleFormat || opts.InBitsPerSample != 32 || opts.InChannelMask != 0x3F || opts.InNumChannels != 6 {
+				t.Errorf("%s/%s: unexpected options %#v", order, test.name, opts)
+			}
+		}
+	}
+}
+
+func Test_ReadWavHeader_UnsupportedFormat(t *testing.T) {
+	le := binary.LittleEndian
+	extensible := wavFormat{AudioFormat: WAVE_FORMAT_EXTENSIBLE, NumChannels: 2, SampleRate: 44100, BlockAlign: 4, BitsPerSample: 16}
+	adpcm := wavFormat{AudioFormat: 2, NumChannels: 2, SampleRate: 44100, BlockAlign: 4, BitsPerSample: 4}
+	tests := []struct {
+		name     string
+		fmtChunk WavChunk
+		expected error
+	}{
+		{"extensible adpcm", buildFmt(le, extensible, buildExtensible(le, 16, 3, 2)), UnsupportedWavFormatError{AudioFormat: 2, Extensible: true}},
+		{"adpcm", buildFmt(le, adpcm, []byte{0, 0}), UnsupportedWavFormatError{AudioFormat: 2}},
+		{"short extension", buildFmt(le, extensible, []byte{0, 0}), ErrInvalidFormatExtensible},
+	}
+	for _, test := range tests {
+		pcm := []byte{1, 2, 3, 4}
+		reader := bytes.NewReader(buildWav(le, test.fmtChunk, WavChunk{Id: subChunk2Id, Data: pcm}))
+		hdr, err := ReadWavHeader(reader)
+		if err != test.expected {
+			t.Errorf("%s: expected %#v, got %#v", test.name, test.expected, err)
+			continue
+		}
+		if _, ok := err.(UnsupportedWavFormatError); ok {
+			if remaining, _ := ioutil.ReadAll(reader); hdr == nil || !bytes.Equal(remaining, pcm) {
+				t.Errorf("%s: expected header and reader positioned at data", test.name)
+			}
+		}
+	}
+}